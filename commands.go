@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/chimera-rpg/go-editor/data"
+	"github.com/chimera-rpg/go-editor/editor"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// command is a subcommand run from the command line in place of, or before, the editor.
+type command struct {
+	args        string
+	description string
+	// run runs the command and returns the exit status. If setup is not nil, the editor is started and setup is called before it is shown, and the process exits with the editor's ExitCode instead.
+	run func(dataManager *data.Manager, args []string) (status int, setup func(e *editor.Editor))
+}
+
+var commands = map[string]command{
+	"merge": {
+		args:        "[-n] base ours theirs [merged]",
+		description: "Three-way merge a map file, writing the result to merged or ours. Conflicts are opened in the editor unless -n is given.",
+		run:         runMerge,
+	},
+	"diff": {
+		args:        "old new",
+		description: "Print the properties and tiles that differ between two versions of a map file. Exits with 1 if they differ.",
+		run:         runDiff,
+	},
+	"format": {
+		args:        "[-l] [file...]",
+		description: "Rewrite map files in the canonical format. With no files every map under the maps path is formatted. With -l the files that aren't formatted are listed instead and the exit status is 1 if there are any.",
+		run:         runFormat,
+	},
+	"migrate": {
+		args:        "[-n] [file...]",
+		description: "Upgrade map files to the current schema version. With no files every map under the maps path is upgraded. With -n the pending migrations are reported without changing anything.",
+		run:         runMigrate,
+	},
+}
+
+// usage prints the available commands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command]\n\nWith no command the editor is started.\n\nCommands:\n", filepath.Base(os.Args[0]))
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n    \t%s\n", name, commands[name].args, commands[name].description)
+	}
+}
+
+// runMerge merges the base, ours, and theirs versions of a map file. It is suited for use as a git merge driver, "chimera-editor merge -n %O %A %B", or as a git mergetool, "chimera-editor merge $BASE $LOCAL $REMOTE $MERGED".
+func runMerge(dataManager *data.Manager, args []string) (int, func(e *editor.Editor)) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	noEditor := flags.Bool("n", false, "don't open the editor for conflicts")
+	flags.Parse(args)
+	if flags.NArg() < 3 || flags.NArg() > 4 {
+		log.Errorln("merge needs base, ours, and theirs map files and an optional merged file")
+		flags.Usage()
+		return 2, nil
+	}
+	output := flags.Arg(1)
+	if flags.NArg() == 4 {
+		output = flags.Arg(3)
+	}
+	output, err := filepath.Abs(output)
+	if err != nil {
+		log.Errorln(err)
+		return 2, nil
+	}
+
+	var sides [3]map[string]*sdata.Map
+	for i := range sides {
+		if sides[i], err = dataManager.LoadMap(flags.Arg(i)); err != nil {
+			log.Errorln(err)
+			return 2, nil
+		}
+	}
+
+	merged, conflicts := data.MergeMaps(sides[data.MergeBase], sides[data.MergeOurs], sides[data.MergeTheirs])
+	if err := dataManager.SaveMap(output, merged); err != nil {
+		log.Errorln(err)
+		return 2, nil
+	}
+	if len(conflicts) == 0 {
+		log.Printf("Merged %s without conflicts\n", output)
+		return 0, nil
+	}
+	for _, c := range conflicts {
+		switch c.Property {
+		case "":
+			log.Warnf("%s: deleted on one side and changed on the other\n", c.Map)
+		case "Size":
+			log.Warnf("%s: resized on one side and changed on the other\n", c.Map)
+		case "Tile":
+			log.Warnf("%s: tile %dx%dx%d changed on both sides\n", c.Map, c.X, c.Z, c.Y)
+		default:
+			log.Warnf("%s: %s changed on both sides\n", c.Map, c.Property)
+		}
+	}
+	if *noEditor {
+		return 1, nil
+	}
+	// The conflicts are still unresolved, so the editor's ExitCode stays non-zero until they are resolved and saved.
+	return 1, func(e *editor.Editor) {
+		e.ShowMergeConflicts(output, conflicts)
+	}
+}
+
+// runDiff prints a summary of the changes between two versions of a map file, such as for a review comment.
+func runDiff(dataManager *data.Manager, args []string) (int, func(e *editor.Editor)) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		log.Errorln("diff needs an old and a new map file")
+		flags.Usage()
+		return 2, nil
+	}
+	oldMaps, err := dataManager.LoadMap(flags.Arg(0))
+	if err != nil {
+		log.Errorln(err)
+		return 2, nil
+	}
+	newMaps, err := dataManager.LoadMap(flags.Arg(1))
+	if err != nil {
+		log.Errorln(err)
+		return 2, nil
+	}
+	diffs := data.DiffMaps(oldMaps, newMaps)
+	if len(diffs) == 0 {
+		return 0, nil
+	}
+	fmt.Print(data.FormatDiff(diffs))
+	return 1, nil
+}
+
+// runFormat rewrites map files in place in the canonical format.
+func runFormat(dataManager *data.Manager, args []string) (int, func(e *editor.Editor)) {
+	flags := flag.NewFlagSet("format", flag.ExitOnError)
+	list := flags.Bool("l", false, "list unformatted files instead of rewriting them")
+	flags.Parse(args)
+	files := flags.Args()
+	if len(files) == 0 {
+		var err error
+		if files, err = dataManager.GetMapFiles(); err != nil {
+			log.Errorln(err)
+			return 2, nil
+		}
+	}
+
+	status := 0
+	for _, file := range files {
+		r, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Errorln(err)
+			status = 2
+			continue
+		}
+		maps, err := dataManager.LoadMap(file)
+		if err != nil {
+			log.Errorf("%s: %s\n", file, err)
+			status = 2
+			continue
+		}
+		out, err := data.FormatMaps(maps)
+		if err != nil {
+			log.Errorf("%s: %s\n", file, err)
+			status = 2
+			continue
+		}
+		if bytes.Equal(r, out) {
+			continue
+		}
+		if *list {
+			fmt.Println(file)
+			if status == 0 {
+				status = 1
+			}
+			continue
+		}
+		if err := data.WriteFileAtomic(file, out, 0644); err != nil {
+			log.Errorln(err)
+			status = 2
+		}
+	}
+	return status, nil
+}
+
+// runMigrate upgrades map files to the current schema version, reporting what was or would be done to each.
+func runMigrate(dataManager *data.Manager, args []string) (int, func(e *editor.Editor)) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("n", false, "report pending migrations without changing any files")
+	flags.Parse(args)
+	files := flags.Args()
+	if len(files) == 0 {
+		var err error
+		if files, err = dataManager.GetMapFiles(); err != nil {
+			log.Errorln(err)
+			return 2, nil
+		}
+	}
+
+	status := 0
+	upgraded := 0
+	for _, file := range files {
+		r, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Errorln(err)
+			status = 2
+			continue
+		}
+		version := data.GetMapVersion(r)
+		pending := data.PendingMapMigrations(version)
+		if len(pending) == 0 {
+			continue
+		}
+		fmt.Printf("%s: version %d -> %d\n", file, version, data.CurrentMapVersion())
+		for _, m := range pending {
+			fmt.Printf("  %d: %s\n", m.Version, m.Description)
+		}
+		// Loading runs the migrations, so a dry run still reports any that fail.
+		maps, err := dataManager.LoadMap(file)
+		if err != nil {
+			log.Errorf("%s: %s\n", file, err)
+			status = 2
+			continue
+		}
+		if !*dryRun {
+			if err := dataManager.SaveMap(file, maps); err != nil {
+				log.Errorln(err)
+				status = 2
+				continue
+			}
+		}
+		upgraded++
+	}
+	if *dryRun {
+		fmt.Printf("%d of %d map files need upgrading to version %d\n", upgraded, len(files), data.CurrentMapVersion())
+	} else {
+		fmt.Printf("Upgraded %d of %d map files to version %d\n", upgraded, len(files), data.CurrentMapVersion())
+	}
+	return status, nil
+}
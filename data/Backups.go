@@ -0,0 +1,133 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMapBackups is the number of backups kept for each map file if the editor config does not say otherwise.
+const DefaultMapBackups = 10
+
+// backupTimeFormat is the timestamp used to name backup files. It sorts in chronological order.
+const backupTimeFormat = "20060102-150405.000"
+
+// MapBackup is a previous version of a map file.
+type MapBackup struct {
+	Path string
+	Time time.Time
+}
+
+// WriteFileAtomic writes data to a temporary file alongside filename, syncs it to disk, then renames it over filename. A failed write leaves any existing file untouched.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(filename)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash. Not every platform supports this, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// getMapBackupPath returns the directory that holds the backups of the given map file.
+func (m *Manager) getMapBackupPath(filename string) string {
+	rel, err := m.GetRelativeMapPath(filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(filename)
+	}
+	return m.GetEtcPath("backups", rel)
+}
+
+// BackupMap copies the current contents of the map file into its backup directory, then removes the oldest backups beyond the configured count.
+func (m *Manager) BackupMap(filename string) error {
+	count := m.EditorConfig.MapBackups
+	if count == 0 {
+		count = DefaultMapBackups
+	}
+	if count < 0 {
+		return nil
+	}
+	r, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	dir := m.getMapBackupPath(filename)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(filepath.Join(dir, time.Now().Format(backupTimeFormat)+".map.yaml"), r, 0644); err != nil {
+		return err
+	}
+
+	backups, err := m.GetMapBackups(filename)
+	if err != nil {
+		return err
+	}
+	for i := count; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMapBackups returns the backups of the given map file, newest first.
+func (m *Manager) GetMapBackups(filename string) (backups []MapBackup, err error) {
+	dir := m.getMapBackupPath(filename)
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".map.yaml") {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(f.Name(), ".map.yaml"), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, MapBackup{
+			Path: filepath.Join(dir, f.Name()),
+			Time: t,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return
+}
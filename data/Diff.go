@@ -0,0 +1,159 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Kinds of tile changes between two versions of a map.
+const (
+	TileAdded = iota
+	TileRemoved
+	TileChanged
+)
+
+// TileChangeNames are the display names of the kinds of tile changes.
+var TileChangeNames = []string{"added", "removed", "changed"}
+
+// TileChange is a tile whose archetypes differ between two versions of a map.
+type TileChange struct {
+	Y, X, Z  int
+	Kind     int
+	Old, New []sdata.Archetype
+}
+
+// PropertyChange is a map property that differs between two versions of a map.
+type PropertyChange struct {
+	Name     string
+	Old, New interface{}
+}
+
+// MapDiff is the difference between two versions of a single map in a map file.
+type MapDiff struct {
+	Map        string
+	Added      bool
+	Removed    bool
+	Properties []PropertyChange
+	Tiles      []TileChange
+}
+
+// DiffMaps compares two versions of the maps in a map file and returns the maps that differ, sorted by name.
+func DiffMaps(oldMaps, newMaps map[string]*sdata.Map) (diffs []MapDiff) {
+	names := make(map[string]struct{})
+	for name := range oldMaps {
+		names[name] = struct{}{}
+	}
+	for name := range newMaps {
+		names[name] = struct{}{}
+	}
+	var sortedNames []string
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	for _, name := range sortedNames {
+		d := DiffMap(oldMaps[name], newMaps[name])
+		d.Map = name
+		if d.Added || d.Removed || len(d.Properties) > 0 || len(d.Tiles) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return
+}
+
+// DiffMap compares two versions of a map. Either may be nil if the map was added or removed.
+func DiffMap(oldMap, newMap *sdata.Map) (d MapDiff) {
+	d.Added = oldMap == nil && newMap != nil
+	d.Removed = oldMap != nil && newMap == nil
+	if oldMap == nil {
+		oldMap = &sdata.Map{}
+	}
+	if newMap == nil {
+		newMap = &sdata.Map{}
+	}
+
+	vo, vn := reflect.ValueOf(oldMap).Elem(), reflect.ValueOf(newMap).Elem()
+	for i := 0; i < vo.NumField(); i++ {
+		field := vo.Type().Field(i)
+		if field.Name == "Tiles" || field.PkgPath != "" {
+			continue
+		}
+		fo, fn := vo.Field(i).Interface(), vn.Field(i).Interface()
+		if !reflect.DeepEqual(fo, fn) {
+			d.Properties = append(d.Properties, PropertyChange{Name: field.Name, Old: fo, New: fn})
+		}
+	}
+
+	h, w, depth := maxInt(oldMap.Height, newMap.Height), maxInt(oldMap.Width, newMap.Width), maxInt(oldMap.Depth, newMap.Depth)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for z := 0; z < depth; z++ {
+				to, tn := getMapTile(oldMap, y, x, z), getMapTile(newMap, y, x, z)
+				if tilesEqual(to, tn) {
+					continue
+				}
+				kind := TileChanged
+				if len(to) == 0 {
+					kind = TileAdded
+				} else if len(tn) == 0 {
+					kind = TileRemoved
+				}
+				d.Tiles = append(d.Tiles, TileChange{Y: y, X: x, Z: z, Kind: kind, Old: to, New: tn})
+			}
+		}
+	}
+	return
+}
+
+// DescribeTile returns the archetype names of a tile stack from top to bottom, separated by sep.
+func DescribeTile(archs []sdata.Archetype, sep string) string {
+	if len(archs) == 0 {
+		return "(empty)"
+	}
+	var names []string
+	for i := len(archs) - 1; i >= 0; i-- {
+		names = append(names, getArchName(archs[i]))
+	}
+	return strings.Join(names, sep)
+}
+
+// getArchName returns the name of the archetype a placed archetype is based on.
+func getArchName(a sdata.Archetype) string {
+	if a.Arch == "" && len(a.Archs) > 0 {
+		return a.Archs[0]
+	}
+	return a.Arch
+}
+
+// FormatDiff returns a textual summary of the diffs, with one line per changed property or tile.
+func FormatDiff(diffs []MapDiff) string {
+	var b strings.Builder
+	for _, d := range diffs {
+		switch {
+		case d.Added:
+			fmt.Fprintf(&b, "%s: added\n", d.Map)
+		case d.Removed:
+			fmt.Fprintf(&b, "%s: removed\n", d.Map)
+			continue
+		default:
+			fmt.Fprintf(&b, "%s:\n", d.Map)
+		}
+		for _, p := range d.Properties {
+			fmt.Fprintf(&b, "  %s: %v -> %v\n", p.Name, p.Old, p.New)
+		}
+		counts := make([]int, len(TileChangeNames))
+		for _, t := range d.Tiles {
+			counts[t.Kind]++
+			fmt.Fprintf(&b, "  tile %dx%dx%d %s: %s -> %s\n", t.X, t.Z, t.Y, TileChangeNames[t.Kind], DescribeTile(t.Old, ", "), DescribeTile(t.New, ", "))
+		}
+		if len(d.Tiles) > 0 {
+			fmt.Fprintf(&b, "  %d tiles added, %d removed, %d changed\n", counts[TileAdded], counts[TileRemoved], counts[TileChanged])
+		}
+	}
+	return b.String()
+}
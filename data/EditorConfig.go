@@ -2,7 +2,6 @@ package data
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 
@@ -11,8 +10,10 @@ import (
 )
 
 type EditorConfig struct {
-	filePath string
-	OpenMaps []string
+	filePath         string
+	OpenMaps         []string
+	MapBackups       int // Number of backups to keep for each map file. 0 uses DefaultMapBackups and a negative value disables backups.
+	AutosaveInterval int // Seconds between autosaves of unsaved maps. 0 uses DefaultAutosaveInterval and a negative value disables autosaving.
 }
 
 // Save saves the configuration to disk.
@@ -26,7 +27,7 @@ func (e *EditorConfig) Save() (err error) {
 	// Write out default config.
 	log.Printf("Saving config \"%s\"\n", e.filePath)
 	bytes, _ := yaml.Marshal(e)
-	err = ioutil.WriteFile(e.filePath, bytes, 0644)
+	err = WriteFileAtomic(e.filePath, bytes, 0644)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,191 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+	"gopkg.in/yaml.v2"
+)
+
+// tilesKey returns the YAML key of the map's Tiles field.
+func tilesKey() string {
+	field, _ := reflect.TypeOf(sdata.Map{}).FieldByName("Tiles")
+	if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// indentYAML prefixes every non-empty line of the YAML with the given indent.
+func indentYAML(b *bytes.Buffer, src []byte, indent string) {
+	for _, line := range strings.SplitAfter(string(src), "\n") {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(indent)
+		}
+		b.WriteString(line)
+	}
+}
+
+// marshalStack returns a tile stack as a single line of flow YAML, or as block YAML if it can't be kept on one line.
+func marshalStack(archs []sdata.Archetype) (out string, flow bool, err error) {
+	if len(archs) == 0 {
+		return "[]", true, nil
+	}
+	f, err := yaml.Marshal(struct {
+		S []sdata.Archetype `yaml:"s,flow"`
+	}{archs})
+	if err != nil {
+		return "", false, err
+	}
+	if s := strings.TrimSuffix(strings.TrimPrefix(string(f), "s: "), "\n"); !strings.Contains(s, "\n") {
+		return s, true, nil
+	}
+	b, err := yaml.Marshal(archs)
+	return string(b), false, err
+}
+
+// maxAnchoredNodes is the most YAML nodes a map file can have and still use anchors. yaml.v2 rejects larger documents as excessive aliasing once enough of their nodes come from aliases.
+const maxAnchoredNodes = 400000
+
+// countNodes returns the number of YAML nodes in a decoded document.
+func countNodes(v interface{}) int {
+	n := 1
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			n += countNodes(e)
+		}
+	case map[interface{}]interface{}:
+		for k, e := range v {
+			n += countNodes(k) + countNodes(e)
+		}
+	}
+	return n
+}
+
+// FormatMaps returns the maps in the canonical map file format, starting with the schema version header. Maps are sorted by name and each tile stack is written on a single line. Unless the file has more than maxAnchoredNodes nodes, stacks that occur more than once are written once with an anchor and referred to by alias afterwards. The output is plain YAML that loads the same as the default encoding, and an error is returned if it doesn't.
+func FormatMaps(maps map[string]*sdata.Map) ([]byte, error) {
+	var names []string
+	for name := range maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plain, err := yaml.Marshal(maps)
+	if err != nil {
+		return nil, err
+	}
+	var nodes interface{}
+	if err := yaml.Unmarshal(plain, &nodes); err != nil {
+		return nil, err
+	}
+	anchored := countNodes(nodes) <= maxAnchoredNodes
+
+	key := tilesKey()
+	var b bytes.Buffer
+	b.WriteString(mapVersionHeader())
+	if len(names) == 0 {
+		// An empty document would load as no map at all rather than as an empty one.
+		b.WriteString("{}\n")
+	}
+	for _, name := range names {
+		sm := maps[name]
+		k, err := yaml.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%s:\n", strings.TrimSuffix(string(k), "\n"))
+		if sm == nil {
+			continue
+		}
+
+		// Write every other property as usual.
+		props := *sm
+		props.Tiles = nil
+		p, err := yaml.Marshal(&props)
+		if err != nil {
+			return nil, err
+		}
+		var kept []string
+		for _, line := range strings.SplitAfter(string(p), "\n") {
+			if !strings.HasPrefix(line, key+":") && strings.TrimSpace(line) != "{}" {
+				kept = append(kept, line)
+			}
+		}
+		indentYAML(&b, []byte(strings.Join(kept, "")), "  ")
+		if len(sm.Tiles) == 0 {
+			fmt.Fprintf(&b, "  %s: []\n", key)
+			continue
+		}
+
+		// Count the stacks so that repeated ones can be anchored.
+		stacks := make(map[string]int)
+		for y := range sm.Tiles {
+			for x := range sm.Tiles[y] {
+				for z := range sm.Tiles[y][x] {
+					s, flow, err := marshalStack(sm.Tiles[y][x][z])
+					if err != nil {
+						return nil, err
+					}
+					if flow && len(sm.Tiles[y][x][z]) > 0 {
+						stacks[s]++
+					}
+				}
+			}
+		}
+		anchors := make(map[string]string)
+
+		fmt.Fprintf(&b, "  %s:\n", key)
+		for y := range sm.Tiles {
+			if len(sm.Tiles[y]) == 0 {
+				b.WriteString("  - []\n")
+				continue
+			}
+			fmt.Fprintf(&b, "  - # y %d\n", y)
+			for x := range sm.Tiles[y] {
+				if len(sm.Tiles[y][x]) == 0 {
+					b.WriteString("    - []\n")
+					continue
+				}
+				fmt.Fprintf(&b, "    - # x %d\n", x)
+				for z := range sm.Tiles[y][x] {
+					s, flow, _ := marshalStack(sm.Tiles[y][x][z])
+					if !flow {
+						b.WriteString("      -\n")
+						indentYAML(&b, []byte(s), "        ")
+						continue
+					}
+					if anchored && stacks[s] > 1 {
+						if anchor, ok := anchors[s]; ok {
+							fmt.Fprintf(&b, "      - *%s\n", anchor)
+							continue
+						}
+						anchors[s] = fmt.Sprintf("t%d", len(anchors)+1)
+						fmt.Fprintf(&b, "      - &%s %s\n", anchors[s], s)
+						continue
+					}
+					fmt.Fprintf(&b, "      - %s\n", s)
+				}
+			}
+		}
+	}
+
+	// Make sure the canonical output loads the same as the default encoding.
+	var want map[string]*sdata.Map
+	if err := yaml.Unmarshal(plain, &want); err != nil {
+		return nil, err
+	}
+	got, err := UnmarshalMaps(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("canonical map output doesn't load: %w", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return nil, errors.New("canonical map output doesn't load the same as the maps")
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFormatMaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		maps       map[string]*sdata.Map
+		wantAnchor bool
+	}{
+		{
+			name: "no maps",
+			maps: map[string]*sdata.Map{},
+		},
+		{
+			name: "no tiles",
+			maps: map[string]*sdata.Map{"a": {Name: "A"}},
+		},
+		{
+			name: "empty tiles",
+			maps: map[string]*sdata.Map{"a": testMap("A", 2, 2, 2)},
+		},
+		{
+			name: "unique stacks",
+			maps: map[string]*sdata.Map{"a": testMap("A", 1, 2, 1, testTile{0, 0, 0, "floor"}, testTile{0, 1, 0, "wall"})},
+		},
+		{
+			name:       "repeated stacks",
+			maps:       map[string]*sdata.Map{"a": testMap("A", 1, 2, 2, testTile{0, 0, 0, "floor"}, testTile{0, 1, 0, "floor"}, testTile{0, 1, 1, "wall"})},
+			wantAnchor: true,
+		},
+		{
+			name: "several maps",
+			maps: map[string]*sdata.Map{
+				"b": testMap("B", 1, 1, 1, testTile{0, 0, 0, "wall"}),
+				"a": testMap("A", 1, 1, 2, testTile{0, 0, 1, "floor"}),
+			},
+		},
+		{
+			name: "quoted names",
+			maps: map[string]*sdata.Map{"a: b": testMap("A: B", 1, 1, 1, testTile{0, 0, 0, "floor"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := FormatMaps(tt.maps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(out), mapVersionHeader()) {
+				t.Errorf("output doesn't start with the version header:\n%s", out)
+			}
+			if GetMapVersion(out) != CurrentMapVersion() {
+				t.Errorf("output is version %d, want %d", GetMapVersion(out), CurrentMapVersion())
+			}
+			if got := strings.Contains(string(out), "&t1"); got != tt.wantAnchor {
+				t.Errorf("anchored is %t, want %t:\n%s", got, tt.wantAnchor, out)
+			}
+
+			// The output loads the same as the default encoding.
+			plain, err := yaml.Marshal(tt.maps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want map[string]*sdata.Map
+			if err := yaml.Unmarshal(plain, &want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalMaps(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("loaded %v, want %v", got, want)
+			}
+
+			// Formatting the loaded maps again gives the same output.
+			again, err := FormatMaps(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, again) {
+				t.Errorf("formatting again gave:\n%s\nwant:\n%s", again, out)
+			}
+		})
+	}
+}
@@ -217,25 +217,42 @@ func (m *Manager) acquireArchetypesPath() (err error) {
 	return
 }
 
+// GetMapFiles returns the full paths of every map file under the MapsPath.
+func (m *Manager) GetMapFiles() (files []string, err error) {
+	err = filepath.Walk(m.MapsPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(file, ".map.yaml") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return
+}
+
 func (m *Manager) LoadMap(filepath string) (maps map[string]*sdata.Map, err error) {
 	r, err := ioutil.ReadFile(filepath)
 	if err != nil {
 		return
 	}
-
-	if err = yaml.Unmarshal(r, &maps); err != nil {
+	if r, _, err = MigrateMapData(r); err != nil {
 		return
 	}
-	return
+	return UnmarshalMaps(r)
 }
 
 func (m *Manager) SaveMap(filepath string, maps map[string]*sdata.Map) (err error) {
-	out, err := yaml.Marshal(maps)
+	out, err := FormatMaps(maps)
 	if err != nil {
 		return err
 	}
 
-	err = ioutil.WriteFile(filepath, out, 0644)
+	if err = m.BackupMap(filepath); err != nil {
+		log.Printf("Couldn't back up map \"%s\": %s\n", filepath, err)
+	}
+
+	err = WriteFileAtomic(filepath, out, 0644)
 	if err != nil {
 		return err
 	}
@@ -746,6 +763,113 @@ func (m *Manager) rGetArchDimensions(a *sdata.Archetype, h, w, d *uint8) {
 	}
 }
 
+// GetArchLight returns the light intensity, from 0 to 1, and the radius in tiles that an archetype emits. The "Light" field is resolved through the archetype's ancestry and may either be a number, treated as the radius at full intensity, or a structure with Intensity and Radius fields.
+func (m *Manager) GetArchLight(a *sdata.Archetype) (intensity float64, radius int) {
+	f := m.GetArchField(a, "Light")
+	if !f.IsValid() {
+		return 0, 0
+	}
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return 0, 0
+		}
+		f = f.Elem()
+	}
+	toFloat := func(v reflect.Value) (float64, bool) {
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), true
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), true
+		}
+		return 0, false
+	}
+	if f.Kind() == reflect.Struct {
+		if v, ok := toFloat(f.FieldByName("Intensity")); ok {
+			intensity = v
+		}
+		if v, ok := toFloat(f.FieldByName("Radius")); ok {
+			radius = int(v)
+		}
+	} else if v, ok := toFloat(f); ok {
+		intensity = 1
+		radius = int(v)
+	}
+	if intensity > 1 {
+		intensity = 1
+	}
+	if radius <= 0 || intensity <= 0 {
+		return 0, 0
+	}
+	return
+}
+
+// GetArchMatter returns the matter the archetype is made of and the matter it blocks, resolved through its ancestry.
+func (m *Manager) GetArchMatter(a *sdata.Archetype) (matter, blocking uint64) {
+	return m.getArchUint(a, "Matter"), m.getArchUint(a, "Blocking")
+}
+
+// getArchUint returns the first non-zero unsigned value of the given field in the archetype or its ancestry.
+func (m *Manager) getArchUint(a *sdata.Archetype, field string) uint64 {
+	toUint := func(f reflect.Value) uint64 {
+		if f.IsValid() && f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				return 0
+			}
+			f = f.Elem()
+		}
+		if !f.IsValid() {
+			return 0
+		}
+		switch f.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return f.Uint()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if f.Int() > 0 {
+				return uint64(f.Int())
+			}
+		}
+		return 0
+	}
+	if v := toUint(reflect.ValueOf(a).Elem().FieldByName(field)); v != 0 {
+		return v
+	}
+	for _, name := range append([]string{a.Arch}, a.Archs...) {
+		if o := m.GetArchetype(name); name != "" && o != nil {
+			if v := m.getArchUint(o, field); v != 0 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// ArchExit is the destination of an exit or teleporter archetype.
+type ArchExit struct {
+	Map     string // Name of the target map.
+	Y, X, Z int
+}
+
+// GetArchExit returns the exit destination of the archetype, resolved through its ancestry.
+func (m *Manager) GetArchExit(a *sdata.Archetype) (exit ArchExit, ok bool) {
+	f := m.GetArchField(a, "Exit")
+	if !f.IsValid() || f.IsNil() {
+		return
+	}
+	e, ok := f.Interface().(*sdata.ArchetypeExit)
+	if !ok || e.Name == "" {
+		return exit, false
+	}
+	return ArchExit{
+		Map: e.Name,
+		Y:   e.Y,
+		X:   e.X,
+		Z:   e.Z,
+	}, true
+}
+
 func (m *Manager) GetArchImage(a *sdata.Archetype, scale float64) (img image.Image, err error) {
 	anim, face := m.GetAnimAndFace(a, "", "")
 
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"reflect"
+	"sort"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Sides of a three-way merge.
+const (
+	MergeBase = iota
+	MergeOurs
+	MergeTheirs
+)
+
+// MergeSideNames are the display names of the sides of a merge.
+var MergeSideNames = []string{"Base", "Ours", "Theirs"}
+
+// MergeConflict is a map, map property, or tile that was changed differently on both sides of a merge. The merged result keeps our side.
+type MergeConflict struct {
+	Map      string
+	Property string // Name of the conflicting property, "Tile" for a tile conflict, "Size" for a map resized on one side and changed on the other, or empty for a map deleted on one side and changed on the other.
+	Y, X, Z  int
+	Values   [3]interface{} // Base, ours, and theirs values. Tiles are []sdata.Archetype and maps are *sdata.Map.
+}
+
+// tilesEqual returns whether two tile stacks are the same, treating missing and empty stacks alike.
+func tilesEqual(a, b []sdata.Archetype) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// getMapTile returns the tile stack at the given coordinates, or nil if the map is missing or too small.
+func getMapTile(sm *sdata.Map, y, x, z int) []sdata.Archetype {
+	if sm == nil || y >= len(sm.Tiles) || x >= len(sm.Tiles[y]) || z >= len(sm.Tiles[y][x]) {
+		return nil
+	}
+	return sm.Tiles[y][x][z]
+}
+
+// MergeMaps performs a three-way merge of the maps in a map file. Maps, properties, and individual tiles changed on only one side are taken from that side. Anything changed differently on both sides keeps our version and is returned as a conflict.
+func MergeMaps(base, ours, theirs map[string]*sdata.Map) (merged map[string]*sdata.Map, conflicts []MergeConflict) {
+	merged = make(map[string]*sdata.Map)
+
+	names := make(map[string]struct{})
+	for _, maps := range []map[string]*sdata.Map{base, ours, theirs} {
+		for name := range maps {
+			names[name] = struct{}{}
+		}
+	}
+	var sortedNames []string
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	for _, name := range sortedNames {
+		b, o, t := base[name], ours[name], theirs[name]
+		switch {
+		case o == nil && t == nil:
+			// Deleted on both sides.
+		case reflect.DeepEqual(o, t):
+			merged[name] = o
+		case b != nil && o == nil:
+			if !reflect.DeepEqual(b, t) {
+				conflicts = append(conflicts, MergeConflict{Map: name, Values: [3]interface{}{b, o, t}})
+			}
+		case b != nil && t == nil:
+			merged[name] = o
+			if !reflect.DeepEqual(b, o) {
+				conflicts = append(conflicts, MergeConflict{Map: name, Values: [3]interface{}{b, o, t}})
+			}
+		case o == nil:
+			merged[name] = t
+		case t == nil:
+			merged[name] = o
+		default:
+			m, c := mergeMap(name, b, o, t)
+			merged[name] = m
+			conflicts = append(conflicts, c...)
+		}
+	}
+	return
+}
+
+// mergeMap merges a single map whose base may be nil if it was added on both sides.
+func mergeMap(name string, base, ours, theirs *sdata.Map) (merged *sdata.Map, conflicts []MergeConflict) {
+	if base == nil {
+		base = &sdata.Map{}
+	} else if resized(ours, theirs) && !reflect.DeepEqual(base, ours) && !reflect.DeepEqual(base, theirs) {
+		// Tiles are matched by their coordinates, which no longer line up if only one side grew or shrank at a low edge, so the whole map conflicts. Both sides resized to the same size merge tile by tile.
+		return ours, []MergeConflict{{Map: name, Property: "Size", Values: [3]interface{}{base, ours, theirs}}}
+	}
+	merged = &sdata.Map{}
+
+	// Merge every property other than the tiles.
+	vb, vo, vt, vm := reflect.ValueOf(base).Elem(), reflect.ValueOf(ours).Elem(), reflect.ValueOf(theirs).Elem(), reflect.ValueOf(merged).Elem()
+	for i := 0; i < vm.NumField(); i++ {
+		field := vm.Type().Field(i)
+		if field.Name == "Tiles" || field.PkgPath != "" {
+			continue
+		}
+		fb, fo, ft := vb.Field(i).Interface(), vo.Field(i).Interface(), vt.Field(i).Interface()
+		switch {
+		case reflect.DeepEqual(fo, ft), reflect.DeepEqual(fb, ft):
+			vm.Field(i).Set(vo.Field(i))
+		case reflect.DeepEqual(fb, fo):
+			vm.Field(i).Set(vt.Field(i))
+		default:
+			vm.Field(i).Set(vo.Field(i))
+			conflicts = append(conflicts, MergeConflict{Map: name, Property: field.Name, Values: [3]interface{}{fb, fo, ft}})
+		}
+	}
+	// Resized on both sides keeps whichever is largest so that no tiles are lost.
+	for _, c := range conflicts {
+		switch c.Property {
+		case "Height":
+			merged.Height = maxInt(ours.Height, theirs.Height)
+		case "Width":
+			merged.Width = maxInt(ours.Width, theirs.Width)
+		case "Depth":
+			merged.Depth = maxInt(ours.Depth, theirs.Depth)
+		}
+	}
+
+	// Merge the tiles.
+	merged.Tiles = make([][][][]sdata.Archetype, merged.Height)
+	for y := 0; y < merged.Height; y++ {
+		merged.Tiles[y] = make([][][]sdata.Archetype, merged.Width)
+		for x := 0; x < merged.Width; x++ {
+			merged.Tiles[y][x] = make([][]sdata.Archetype, merged.Depth)
+			for z := 0; z < merged.Depth; z++ {
+				tb, to, tt := getMapTile(base, y, x, z), getMapTile(ours, y, x, z), getMapTile(theirs, y, x, z)
+				switch {
+				case tilesEqual(to, tt), tilesEqual(tb, tt):
+					merged.Tiles[y][x][z] = to
+				case tilesEqual(tb, to):
+					merged.Tiles[y][x][z] = tt
+				default:
+					merged.Tiles[y][x][z] = to
+					conflicts = append(conflicts, MergeConflict{Map: name, Property: "Tile", Y: y, X: x, Z: z, Values: [3]interface{}{tb, to, tt}})
+				}
+				if merged.Tiles[y][x][z] == nil {
+					merged.Tiles[y][x][z] = []sdata.Archetype{}
+				}
+			}
+		}
+	}
+	return
+}
+
+// resized returns whether the map's dimensions differ from the base's.
+func resized(base, sm *sdata.Map) bool {
+	return sm.Height != base.Height || sm.Width != base.Width || sm.Depth != base.Depth
+}
+
+// fitTiles resizes the map's tiles to its Height, Width, and Depth, dropping the tiles outside of them and adding empty ones.
+func fitTiles(sm *sdata.Map) {
+	tiles := make([][][][]sdata.Archetype, sm.Height)
+	for y := range tiles {
+		tiles[y] = make([][][]sdata.Archetype, sm.Width)
+		for x := range tiles[y] {
+			tiles[y][x] = make([][]sdata.Archetype, sm.Depth)
+			for z := range tiles[y][x] {
+				if t := getMapTile(sm, y, x, z); t != nil {
+					tiles[y][x][z] = t
+				} else {
+					tiles[y][x][z] = []sdata.Archetype{}
+				}
+			}
+		}
+	}
+	sm.Tiles = tiles
+}
+
+// ResolveMergeConflict applies the given side of a property or tile conflict to the map.
+func ResolveMergeConflict(sm *sdata.Map, c MergeConflict, side int) {
+	switch c.Property {
+	case "", "Size":
+		// Whole map conflicts are resolved by keeping or removing the map.
+	case "Tile":
+		if c.Y < len(sm.Tiles) && c.X < len(sm.Tiles[c.Y]) && c.Z < len(sm.Tiles[c.Y][c.X]) {
+			archs, _ := c.Values[side].([]sdata.Archetype)
+			sm.Tiles[c.Y][c.X][c.Z] = append([]sdata.Archetype{}, archs...)
+		}
+	default:
+		f := reflect.ValueOf(sm).Elem().FieldByName(c.Property)
+		if f.IsValid() && f.CanSet() && c.Values[side] != nil {
+			f.Set(reflect.ValueOf(c.Values[side]))
+		}
+		switch c.Property {
+		case "Height", "Width", "Depth":
+			fitTiles(sm)
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"testing"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+type testTile struct {
+	y, x, z int
+	arch    string
+}
+
+// testMap returns a map of the given size with the given tiles placed.
+func testMap(name string, h, w, d int, tiles ...testTile) *sdata.Map {
+	sm := &sdata.Map{
+		Name:   name,
+		Height: h,
+		Width:  w,
+		Depth:  d,
+	}
+	fitTiles(sm)
+	for _, t := range tiles {
+		sm.Tiles[t.y][t.x][t.z] = append(sm.Tiles[t.y][t.x][t.z], sdata.Archetype{Archs: []string{t.arch}})
+	}
+	return sm
+}
+
+func TestMergeMaps(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, ours, theirs  *sdata.Map
+		want                []MergeConflict // Only Map, Property, Y, X, and Z are compared.
+		wantTile            *testTile       // A tile expected in the merged map.
+		wantH, wantW, wantD int
+	}{
+		{
+			name:   "unchanged",
+			base:   testMap("a", 1, 2, 2),
+			ours:   testMap("a", 1, 2, 2),
+			theirs: testMap("a", 1, 2, 2),
+			wantH:  1, wantW: 2, wantD: 2,
+		},
+		{
+			name:     "tile changed on one side",
+			base:     testMap("a", 1, 2, 2),
+			ours:     testMap("a", 1, 2, 2),
+			theirs:   testMap("a", 1, 2, 2, testTile{0, 1, 0, "wall"}),
+			wantTile: &testTile{0, 1, 0, "wall"},
+			wantH:    1, wantW: 2, wantD: 2,
+		},
+		{
+			name:     "different tiles changed on each side",
+			base:     testMap("a", 1, 2, 2),
+			ours:     testMap("a", 1, 2, 2, testTile{0, 0, 1, "floor"}),
+			theirs:   testMap("a", 1, 2, 2, testTile{0, 1, 0, "wall"}),
+			wantTile: &testTile{0, 1, 0, "wall"},
+			wantH:    1, wantW: 2, wantD: 2,
+		},
+		{
+			name:     "same tile changed on both sides",
+			base:     testMap("a", 1, 2, 2),
+			ours:     testMap("a", 1, 2, 2, testTile{0, 1, 0, "floor"}),
+			theirs:   testMap("a", 1, 2, 2, testTile{0, 1, 0, "wall"}),
+			want:     []MergeConflict{{Map: "a", Property: "Tile", Y: 0, X: 1, Z: 0}},
+			wantTile: &testTile{0, 1, 0, "floor"},
+			wantH:    1, wantW: 2, wantD: 2,
+		},
+		{
+			name:   "property changed on both sides",
+			base:   testMap("a", 1, 2, 2),
+			ours:   testMap("b", 1, 2, 2),
+			theirs: testMap("c", 1, 2, 2),
+			want:   []MergeConflict{{Map: "a", Property: "Name"}},
+			wantH:  1, wantW: 2, wantD: 2,
+		},
+		{
+			name:   "resized on one side and changed on the other",
+			base:   testMap("a", 1, 2, 2),
+			ours:   testMap("a", 1, 3, 2),
+			theirs: testMap("a", 1, 2, 2, testTile{0, 1, 0, "wall"}),
+			want:   []MergeConflict{{Map: "a", Property: "Size"}},
+			wantH:  1, wantW: 3, wantD: 2,
+		},
+		{
+			name:   "resized on one side only",
+			base:   testMap("a", 1, 2, 2),
+			ours:   testMap("a", 1, 2, 2),
+			theirs: testMap("a", 2, 2, 2),
+			wantH:  2, wantW: 2, wantD: 2,
+		},
+		{
+			name:   "resized identically on both sides",
+			base:   testMap("a", 1, 2, 2),
+			ours:   testMap("a", 2, 3, 2),
+			theirs: testMap("a", 2, 3, 2),
+			wantH:  2, wantW: 3, wantD: 2,
+		},
+		{
+			name:     "resized to the same size with different tiles",
+			base:     testMap("a", 1, 2, 2),
+			ours:     testMap("a", 2, 3, 2, testTile{1, 2, 0, "floor"}),
+			theirs:   testMap("a", 2, 3, 2, testTile{0, 0, 1, "wall"}),
+			wantTile: &testTile{1, 2, 0, "floor"},
+			wantH:    2, wantW: 3, wantD: 2,
+		},
+		{
+			name:   "added on both sides with different sizes",
+			ours:   testMap("a", 1, 2, 2),
+			theirs: testMap("a", 1, 3, 2),
+			want:   []MergeConflict{{Map: "a", Property: "Width"}},
+			wantH:  1, wantW: 3, wantD: 2,
+		},
+		{
+			name:   "deleted on one side and changed on the other",
+			base:   testMap("a", 1, 2, 2),
+			theirs: testMap("a", 1, 2, 2, testTile{0, 1, 0, "wall"}),
+			want:   []MergeConflict{{Map: "a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sides := [3]map[string]*sdata.Map{{}, {}, {}}
+			for i, sm := range []*sdata.Map{tt.base, tt.ours, tt.theirs} {
+				if sm != nil {
+					sides[i]["a"] = sm
+				}
+			}
+			merged, conflicts := MergeMaps(sides[MergeBase], sides[MergeOurs], sides[MergeTheirs])
+			if len(conflicts) != len(tt.want) {
+				t.Fatalf("got %d conflicts %v, want %d", len(conflicts), conflicts, len(tt.want))
+			}
+			for i, c := range conflicts {
+				w := tt.want[i]
+				if c.Map != w.Map || c.Property != w.Property || c.Y != w.Y || c.X != w.X || c.Z != w.Z {
+					t.Errorf("conflict %d is %s %q at %dx%dx%d, want %s %q at %dx%dx%d", i, c.Map, c.Property, c.X, c.Z, c.Y, w.Map, w.Property, w.X, w.Z, w.Y)
+				}
+			}
+			sm := merged["a"]
+			if tt.wantH == 0 {
+				return
+			}
+			if sm == nil {
+				t.Fatal("merged map is missing")
+			}
+			if sm.Height != tt.wantH || sm.Width != tt.wantW || sm.Depth != tt.wantD {
+				t.Errorf("merged map is %dx%dx%d, want %dx%dx%d", sm.Width, sm.Depth, sm.Height, tt.wantW, tt.wantD, tt.wantH)
+			}
+			if len(sm.Tiles) != sm.Height || len(sm.Tiles[0]) != sm.Width || len(sm.Tiles[0][0]) != sm.Depth {
+				t.Errorf("merged tiles don't match the map size")
+			}
+			if w := tt.wantTile; w != nil {
+				tiles := getMapTile(sm, w.y, w.x, w.z)
+				if len(tiles) != 1 || len(tiles[0].Archs) != 1 || tiles[0].Archs[0] != w.arch {
+					t.Errorf("tile %dx%dx%d is %v, want %s", w.x, w.z, w.y, tiles, w.arch)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveMergeConflict(t *testing.T) {
+	tests := []struct {
+		name                string
+		conflict            MergeConflict
+		side                int
+		wantName            string
+		wantH, wantW, wantD int
+		wantTile            []string
+	}{
+		{
+			name:     "tile",
+			conflict: MergeConflict{Property: "Tile", Y: 0, X: 1, Z: 0, Values: [3]interface{}{nil, []sdata.Archetype{{Archs: []string{"floor"}}}, []sdata.Archetype{{Archs: []string{"wall"}}}}},
+			side:     MergeTheirs,
+			wantName: "a", wantH: 1, wantW: 2, wantD: 2,
+			wantTile: []string{"wall"},
+		},
+		{
+			name:     "property",
+			conflict: MergeConflict{Property: "Name", Values: [3]interface{}{"a", "b", "c"}},
+			side:     MergeTheirs,
+			wantName: "c", wantH: 1, wantW: 2, wantD: 2,
+		},
+		{
+			name:     "width",
+			conflict: MergeConflict{Property: "Width", Values: [3]interface{}{nil, 2, 3}},
+			side:     MergeTheirs,
+			wantName: "a", wantH: 1, wantW: 3, wantD: 2,
+		},
+		{
+			name:     "whole map",
+			conflict: MergeConflict{Property: "Size"},
+			side:     MergeTheirs,
+			wantName: "a", wantH: 1, wantW: 2, wantD: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := testMap("a", 1, 2, 2)
+			ResolveMergeConflict(sm, tt.conflict, tt.side)
+			if sm.Name != tt.wantName {
+				t.Errorf("name is %q, want %q", sm.Name, tt.wantName)
+			}
+			if sm.Height != tt.wantH || sm.Width != tt.wantW || sm.Depth != tt.wantD {
+				t.Errorf("map is %dx%dx%d, want %dx%dx%d", sm.Width, sm.Depth, sm.Height, tt.wantW, tt.wantD, tt.wantH)
+			}
+			if len(sm.Tiles) != sm.Height || len(sm.Tiles[0]) != sm.Width || len(sm.Tiles[0][0]) != sm.Depth {
+				t.Errorf("tiles don't match the map size")
+			}
+			if tt.wantTile != nil {
+				tiles := getMapTile(sm, tt.conflict.Y, tt.conflict.X, tt.conflict.Z)
+				if len(tiles) != 1 || len(tiles[0].Archs) != 1 || tiles[0].Archs[0] != tt.wantTile[0] {
+					t.Errorf("tile is %v, want %v", tiles, tt.wantTile)
+				}
+			}
+		})
+	}
+}
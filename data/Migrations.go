@@ -0,0 +1,128 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+	"gopkg.in/yaml.v2"
+)
+
+// mapVersionPrefix starts the comment on the first line of a map file that holds its schema version. A comment keeps the file loadable by the server, which reads every top-level key as a map.
+const mapVersionPrefix = "# chimera-map-version:"
+
+// mapVersionHeader returns the first line written to map files.
+func mapVersionHeader() string {
+	return fmt.Sprintf("%s %d\n", mapVersionPrefix, CurrentMapVersion())
+}
+
+// MarshalMaps returns the maps as a map file of the current schema version. Any other writer of map files should use this or FormatMaps so that the version header is kept.
+func MarshalMaps(maps map[string]*sdata.Map) ([]byte, error) {
+	out, err := yaml.Marshal(maps)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(mapVersionHeader()), out...), nil
+}
+
+// UnmarshalMaps returns the maps in the map file contents, which must already be migrated to the current schema version.
+func UnmarshalMaps(r []byte) (maps map[string]*sdata.Map, err error) {
+	err = yaml.Unmarshal(r, &maps)
+	return
+}
+
+// MapMigration upgrades the decoded YAML of a map file from the previous schema version to Version. The maps are keyed by data name as in the file.
+type MapMigration struct {
+	Version     int
+	Description string
+	Migrate     func(maps map[interface{}]interface{}) error
+}
+
+var mapMigrations []MapMigration
+
+// RegisterMapMigration adds a migration to the registry. Migrations run in order of version.
+func RegisterMapMigration(migration MapMigration) {
+	for _, m := range mapMigrations {
+		if m.Version == migration.Version {
+			panic(fmt.Sprintf("map migration %d registered twice", migration.Version))
+		}
+	}
+	mapMigrations = append(mapMigrations, migration)
+	sort.Slice(mapMigrations, func(i, j int) bool {
+		return mapMigrations[i].Version < mapMigrations[j].Version
+	})
+}
+
+// CurrentMapVersion returns the schema version written by SaveMap.
+func CurrentMapVersion() int {
+	if len(mapMigrations) == 0 {
+		return 0
+	}
+	return mapMigrations[len(mapMigrations)-1].Version
+}
+
+// GetMapVersion returns the schema version in the header of the map file contents. Files without a version are version 0.
+func GetMapVersion(r []byte) int {
+	line, _ := bufio.NewReader(bytes.NewReader(r)).ReadString('\n')
+	if !strings.HasPrefix(line, mapVersionPrefix) {
+		return 0
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, mapVersionPrefix)))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// PendingMapMigrations returns the migrations needed to bring a map file of the given version up to date.
+func PendingMapMigrations(version int) (pending []MapMigration) {
+	for _, m := range mapMigrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return
+}
+
+// MigrateMapData runs any pending migrations on the map file contents and returns the upgraded contents along with the migrations that were run.
+func MigrateMapData(r []byte) ([]byte, []MapMigration, error) {
+	version := GetMapVersion(r)
+	if version > CurrentMapVersion() {
+		return nil, nil, fmt.Errorf("map file is schema version %d, but only up to %d is supported", version, CurrentMapVersion())
+	}
+	pending := PendingMapMigrations(version)
+	if len(pending) == 0 {
+		return r, nil, nil
+	}
+	var maps map[interface{}]interface{}
+	if err := yaml.Unmarshal(r, &maps); err != nil {
+		return nil, nil, err
+	}
+	if maps == nil {
+		maps = make(map[interface{}]interface{})
+	}
+	for _, m := range pending {
+		if err := m.Migrate(maps); err != nil {
+			return nil, nil, fmt.Errorf("migrating to schema version %d: %w", m.Version, err)
+		}
+	}
+	out, err := yaml.Marshal(maps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]byte(mapVersionHeader()), out...), pending, nil
+}
+
+func init() {
+	RegisterMapMigration(MapMigration{
+		Version:     1,
+		Description: "Add the schema version",
+		Migrate: func(maps map[interface{}]interface{}) error {
+			return nil
+		},
+	})
+}
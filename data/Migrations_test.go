@@ -0,0 +1,178 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// withMapMigrations replaces the migration registry for the rest of the test.
+func withMapMigrations(t *testing.T, migrations ...MapMigration) {
+	saved := mapMigrations
+	mapMigrations = nil
+	t.Cleanup(func() {
+		mapMigrations = saved
+	})
+	for _, m := range migrations {
+		RegisterMapMigration(m)
+	}
+}
+
+func TestMigrateMapData(t *testing.T) {
+	renameLore := MapMigration{
+		Version:     2,
+		Description: "Rename story to lore",
+		Migrate: func(maps map[interface{}]interface{}) error {
+			for _, sm := range maps {
+				if sm, ok := sm.(map[interface{}]interface{}); ok {
+					if story, ok := sm["story"]; ok {
+						sm["lore"] = story
+						delete(sm, "story")
+					}
+				}
+			}
+			return nil
+		},
+	}
+	failing := MapMigration{
+		Version:     2,
+		Description: "Fail",
+		Migrate: func(maps map[interface{}]interface{}) error {
+			return errors.New("bad map")
+		},
+	}
+	registered := mapMigrations[0]
+
+	tests := []struct {
+		name       string
+		migrations []MapMigration
+		file       string
+		wantRun    int
+		wantLore   string
+		wantErr    string
+	}{
+		{
+			name:       "version 0 without a header",
+			migrations: []MapMigration{registered},
+			file:       "a:\n  name: A\n  lore: Old\n",
+			wantRun:    1,
+			wantLore:   "Old",
+		},
+		{
+			name:       "version 0 runs every migration",
+			migrations: []MapMigration{registered, renameLore},
+			file:       "a:\n  name: A\n  story: Old\n",
+			wantRun:    2,
+			wantLore:   "Old",
+		},
+		{
+			name:       "version 1 runs the newer migrations",
+			migrations: []MapMigration{registered, renameLore},
+			file:       "# chimera-map-version: 1\na:\n  name: A\n  story: Old\n",
+			wantRun:    1,
+			wantLore:   "Old",
+		},
+		{
+			name:       "current version is unchanged",
+			migrations: []MapMigration{registered},
+			file:       "# chimera-map-version: 1\na:\n  name: A\n  lore: Old\n",
+			wantLore:   "Old",
+		},
+		{
+			name:       "empty file",
+			migrations: []MapMigration{registered},
+			file:       "",
+			wantRun:    1,
+		},
+		{
+			name:       "newer version is rejected",
+			migrations: []MapMigration{registered},
+			file:       "# chimera-map-version: 2\na:\n  name: A\n",
+			wantErr:    "map file is schema version 2, but only up to 1 is supported",
+		},
+		{
+			name:       "failing migration",
+			migrations: []MapMigration{registered, failing},
+			file:       "# chimera-map-version: 1\na:\n  name: A\n",
+			wantErr:    "migrating to schema version 2: bad map",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withMapMigrations(t, tt.migrations...)
+			out, run, err := MigrateMapData([]byte(tt.file))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(run) != tt.wantRun {
+				t.Errorf("ran %d migrations, want %d", len(run), tt.wantRun)
+			}
+			if tt.wantRun == 0 && string(out) != tt.file {
+				t.Errorf("unchanged file was rewritten:\n%s", out)
+			}
+			if GetMapVersion(out) != CurrentMapVersion() {
+				t.Errorf("migrated file is version %d, want %d", GetMapVersion(out), CurrentMapVersion())
+			}
+			var maps map[string]map[string]interface{}
+			if err := yaml.Unmarshal(out, &maps); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantLore != "" && maps["a"]["lore"] != tt.wantLore {
+				t.Errorf("migrated maps are %v, want lore %q", maps, tt.wantLore)
+			}
+		})
+	}
+}
+
+func TestGetMapVersion(t *testing.T) {
+	tests := []struct {
+		file string
+		want int
+	}{
+		{"", 0},
+		{"a:\n  name: A\n", 0},
+		{"# chimera-map-version: 3\na:\n", 3},
+		{"# chimera-map-version: 3", 3},
+		{"# chimera-map-version: x\na:\n", 0},
+		{"a:\n# chimera-map-version: 3\n", 0},
+	}
+	for _, tt := range tests {
+		if got := GetMapVersion([]byte(tt.file)); got != tt.want {
+			t.Errorf("GetMapVersion(%q) = %d, want %d", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterMapMigration(t *testing.T) {
+	noop := func(maps map[interface{}]interface{}) error { return nil }
+	withMapMigrations(t,
+		MapMigration{Version: 3, Migrate: noop},
+		MapMigration{Version: 1, Migrate: noop},
+		MapMigration{Version: 2, Migrate: noop},
+	)
+	if CurrentMapVersion() != 3 {
+		t.Errorf("current version is %d, want 3", CurrentMapVersion())
+	}
+	var versions []string
+	for _, m := range PendingMapMigrations(1) {
+		versions = append(versions, fmt.Sprint(m.Version))
+	}
+	if got := strings.Join(versions, ","); got != "2,3" {
+		t.Errorf("pending migrations are %s, want 2,3", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a version twice didn't panic")
+		}
+	}()
+	RegisterMapMigration(MapMigration{Version: 2, Migrate: noop})
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// DefaultAutosaveInterval is how often unsaved maps are autosaved if the editor config does not say otherwise.
+const DefaultAutosaveInterval = 60 * time.Second
+
+// getRecoveryPath returns the autosave file for the given map file.
+func (m *Manager) getRecoveryPath(filename string) string {
+	rel, err := m.GetRelativeMapPath(filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(filename)
+	}
+	return m.GetEtcPath("recovery", rel)
+}
+
+// getUntitledRecoveryPath returns the autosave file for a mapset that has never been saved.
+func (m *Manager) getUntitledRecoveryPath(name string) string {
+	return m.GetEtcPath("recovery-untitled", name)
+}
+
+// NewUntitledRecoveryName returns a unique name to autosave a mapset that has never been saved under.
+func NewUntitledRecoveryName() string {
+	return fmt.Sprintf("untitled-%d.yaml", time.Now().UnixNano())
+}
+
+// GetAutosaveInterval returns the configured autosave interval. A zero interval means autosaving is disabled.
+func (m *Manager) GetAutosaveInterval() time.Duration {
+	if m.EditorConfig.AutosaveInterval < 0 {
+		return 0
+	} else if m.EditorConfig.AutosaveInterval == 0 {
+		return DefaultAutosaveInterval
+	}
+	return time.Duration(m.EditorConfig.AutosaveInterval) * time.Second
+}
+
+// WriteRecovery autosaves the given maps as the recovery file for the map file.
+func (m *Manager) WriteRecovery(filename string, maps map[string]*sdata.Map) error {
+	return writeRecoveryFile(m.getRecoveryPath(filename), maps)
+}
+
+// WriteUntitledRecovery autosaves the given maps of a mapset that has never been saved under the name from NewUntitledRecoveryName.
+func (m *Manager) WriteUntitledRecovery(name string, maps map[string]*sdata.Map) error {
+	return writeRecoveryFile(m.getUntitledRecoveryPath(name), maps)
+}
+
+func writeRecoveryFile(p string, maps map[string]*sdata.Map) error {
+	out, err := MarshalMaps(maps)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	return WriteFileAtomic(p, out, 0644)
+}
+
+// LoadRecovery returns the autosaved maps for the map file if they are newer than the map file itself.
+func (m *Manager) LoadRecovery(filename string) (maps map[string]*sdata.Map, err error) {
+	p := m.getRecoveryPath(filename)
+	rInfo, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filename); err == nil && !rInfo.ModTime().After(info.ModTime()) {
+		return nil, nil
+	}
+	return m.LoadMap(p)
+}
+
+// LoadUntitledRecoveries returns the autosaved maps of every mapset that was never saved, keyed by their untitled names.
+func (m *Manager) LoadUntitledRecoveries() (recoveries map[string]map[string]*sdata.Map, err error) {
+	entries, err := ioutil.ReadDir(m.getUntitledRecoveryPath(""))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	recoveries = make(map[string]map[string]*sdata.Map)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+		maps, err := m.LoadMap(m.getUntitledRecoveryPath(entry.Name()))
+		if err != nil {
+			return recoveries, err
+		}
+		recoveries[entry.Name()] = maps
+	}
+	return recoveries, nil
+}
+
+// ClearRecovery removes the autosaved maps for the map file.
+func (m *Manager) ClearRecovery(filename string) error {
+	return removeRecoveryFile(m.getRecoveryPath(filename))
+}
+
+// ClearUntitledRecovery removes the autosaved maps of a mapset that was never saved.
+func (m *Manager) ClearUntitledRecovery(name string) error {
+	return removeRecoveryFile(m.getUntitledRecoveryPath(name))
+}
+
+func removeRecoveryFile(p string) error {
+	err := os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,710 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+	"gopkg.in/yaml.v2"
+)
+
+// tiledGIDMask clears the flip and rotation flags from a Tiled global tile ID.
+const tiledGIDMask = 0x0FFFFFFF
+
+// TiledMapping maps the tiles, layers, and objects of Tiled maps to archetypes and Y levels. Tiles and objects with an "arch" property and layers with a "y" property don't need to be mapped.
+type TiledMapping struct {
+	Tilesets map[string]map[int]string `yaml:"tilesets"` // Tileset name to tile ID to archetype.
+	Layers   map[string]int            `yaml:"layers"`   // Layer name to Y level.
+	Objects  map[string]string         `yaml:"objects"`  // Object class, type, or name to archetype.
+}
+
+// LoadTiledMapping loads a Tiled mapping file.
+func LoadTiledMapping(filename string) (mapping TiledMapping, err error) {
+	r, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(r, &mapping)
+	return
+}
+
+// TiledImportOptions control how a Tiled map is converted.
+type TiledImportOptions struct {
+	Mapping TiledMapping
+	Stack   bool // Stack every layer at Y 0 instead of giving each layer its own Y level.
+}
+
+// tiledMap is a Tiled map loaded from either TMX or JSON.
+type tiledMap struct {
+	width, height         int
+	tileWidth, tileHeight int
+	layers                []tiledLayer // Layers in drawing order, with groups flattened.
+	tilesets              []tiledTileset
+	properties            map[string]string
+}
+
+type tiledLayer struct {
+	name       string
+	data       []uint32 // Global tile IDs for tile layers.
+	objects    []tiledObject
+	properties map[string]string
+}
+
+type tiledTileset struct {
+	firstGID int
+	name     string
+	tiles    map[int]map[string]string // Tile ID to its properties.
+}
+
+type tiledObject struct {
+	name, class string
+	gid         uint32
+	x, y        float64
+	properties  map[string]string
+}
+
+// Tiled JSON, used for both importing and exporting.
+type tiledJSONProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+type tiledJSONObject struct {
+	ID         int                 `json:"id,omitempty"`
+	Name       string              `json:"name"`
+	Type       string              `json:"type,omitempty"`
+	Class      string              `json:"class,omitempty"`
+	GID        uint32              `json:"gid,omitempty"`
+	X          float64             `json:"x"`
+	Y          float64             `json:"y"`
+	Width      float64             `json:"width"`
+	Height     float64             `json:"height"`
+	Visible    bool                `json:"visible"`
+	Properties []tiledJSONProperty `json:"properties,omitempty"`
+}
+
+type tiledJSONLayer struct {
+	ID          int                 `json:"id,omitempty"`
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Width       int                 `json:"width,omitempty"`
+	Height      int                 `json:"height,omitempty"`
+	X           int                 `json:"x"`
+	Y           int                 `json:"y"`
+	Opacity     float64             `json:"opacity"`
+	Visible     bool                `json:"visible"`
+	Data        json.RawMessage     `json:"data,omitempty"`
+	Encoding    string              `json:"encoding,omitempty"`
+	Compression string              `json:"compression,omitempty"`
+	Chunks      json.RawMessage     `json:"chunks,omitempty"`
+	Objects     []tiledJSONObject   `json:"objects,omitempty"`
+	Layers      []tiledJSONLayer    `json:"layers,omitempty"`
+	Properties  []tiledJSONProperty `json:"properties,omitempty"`
+}
+
+type tiledJSONTile struct {
+	ID          int                 `json:"id"`
+	Image       string              `json:"image,omitempty"`
+	ImageWidth  int                 `json:"imagewidth,omitempty"`
+	ImageHeight int                 `json:"imageheight,omitempty"`
+	Properties  []tiledJSONProperty `json:"properties,omitempty"`
+}
+
+type tiledJSONTileset struct {
+	FirstGID   int             `json:"firstgid"`
+	Source     string          `json:"source,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	TileWidth  int             `json:"tilewidth,omitempty"`
+	TileHeight int             `json:"tileheight,omitempty"`
+	TileCount  int             `json:"tilecount,omitempty"`
+	Columns    int             `json:"columns"`
+	Tiles      []tiledJSONTile `json:"tiles,omitempty"`
+}
+
+type tiledJSONMap struct {
+	Type         string              `json:"type"`
+	Version      string              `json:"version"`
+	Orientation  string              `json:"orientation"`
+	RenderOrder  string              `json:"renderorder"`
+	Infinite     bool                `json:"infinite"`
+	Width        int                 `json:"width"`
+	Height       int                 `json:"height"`
+	TileWidth    int                 `json:"tilewidth"`
+	TileHeight   int                 `json:"tileheight"`
+	NextLayerID  int                 `json:"nextlayerid"`
+	NextObjectID int                 `json:"nextobjectid"`
+	Layers       []tiledJSONLayer    `json:"layers"`
+	Tilesets     []tiledJSONTileset  `json:"tilesets"`
+	Properties   []tiledJSONProperty `json:"properties,omitempty"`
+}
+
+// Tiled TMX and TSX.
+type tmxProperties struct {
+	Properties []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+		Text  string `xml:",chardata"`
+	} `xml:"property"`
+}
+
+type tmxObject struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Data       struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Text        string `xml:",chardata"`
+		Tiles       []struct {
+			GID uint32 `xml:"gid,attr"`
+		} `xml:"tile"`
+		Chunks []struct{} `xml:"chunk"`
+	} `xml:"data"`
+	Objects []tmxObject `xml:"object"`
+	Layers  []tmxLayer  `xml:",any"` // Children of groups.
+}
+
+type tmxTileset struct {
+	FirstGID int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+	Name     string `xml:"name,attr"`
+	Tiles    []struct {
+		ID         int           `xml:"id,attr"`
+		Properties tmxProperties `xml:"properties"`
+	} `xml:"tile"`
+}
+
+type tmxMap struct {
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Infinite   int           `xml:"infinite,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Tilesets   []tmxTileset  `xml:"tileset"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+func (p tmxProperties) toMap() map[string]string {
+	props := make(map[string]string)
+	for _, prop := range p.Properties {
+		if prop.Value != "" {
+			props[prop.Name] = prop.Value
+		} else {
+			props[prop.Name] = prop.Text
+		}
+	}
+	return props
+}
+
+func tiledJSONPropertiesToMap(properties []tiledJSONProperty) map[string]string {
+	props := make(map[string]string)
+	for _, prop := range properties {
+		props[prop.Name] = fmt.Sprint(prop.Value)
+	}
+	return props
+}
+
+// decodeTiledData decodes CSV or base64 encoded, optionally compressed, global tile IDs.
+func decodeTiledData(encoding, compression, text string) ([]uint32, error) {
+	var gids []uint32
+	switch encoding {
+	case "csv":
+		for _, field := range strings.Split(text, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			gid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			return nil, err
+		}
+		switch compression {
+		case "":
+		case "zlib":
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, err
+			}
+			if raw, err = ioutil.ReadAll(r); err != nil {
+				return nil, err
+			}
+		case "gzip":
+			r, err := gzip.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, err
+			}
+			if raw, err = ioutil.ReadAll(r); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported layer compression \"%s\"", compression)
+		}
+		gids = make([]uint32, len(raw)/4)
+		for i := range gids {
+			gids[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported layer encoding \"%s\"", encoding)
+	}
+	return gids, nil
+}
+
+// loadTiledTileset loads an external TSX or JSON tileset.
+func loadTiledTileset(filename string, firstGID int) (ts tiledTileset, err error) {
+	r, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	ts.firstGID = firstGID
+	ts.tiles = make(map[int]map[string]string)
+	if strings.HasSuffix(filename, ".tsx") {
+		var t tmxTileset
+		if err = xml.Unmarshal(r, &t); err != nil {
+			return
+		}
+		ts.name = t.Name
+		for _, tile := range t.Tiles {
+			ts.tiles[tile.ID] = tile.Properties.toMap()
+		}
+		return
+	}
+	var t tiledJSONTileset
+	if err = json.Unmarshal(r, &t); err != nil {
+		return
+	}
+	ts.name = t.Name
+	for _, tile := range t.Tiles {
+		ts.tiles[tile.ID] = tiledJSONPropertiesToMap(tile.Properties)
+	}
+	return
+}
+
+// loadTiledTMX loads a Tiled map in the TMX format.
+func loadTiledTMX(filename string, r []byte) (tm tiledMap, err error) {
+	var t tmxMap
+	if err = xml.Unmarshal(r, &t); err != nil {
+		return
+	}
+	if t.Infinite != 0 {
+		return tm, errors.New("infinite Tiled maps aren't supported")
+	}
+	tm.width, tm.height, tm.tileWidth, tm.tileHeight = t.Width, t.Height, t.TileWidth, t.TileHeight
+	tm.properties = t.Properties.toMap()
+
+	for _, t := range t.Tilesets {
+		if t.Source != "" {
+			ts, err := loadTiledTileset(filepath.Join(filepath.Dir(filename), t.Source), t.FirstGID)
+			if err != nil {
+				return tm, err
+			}
+			tm.tilesets = append(tm.tilesets, ts)
+			continue
+		}
+		ts := tiledTileset{firstGID: t.FirstGID, name: t.Name, tiles: make(map[int]map[string]string)}
+		for _, tile := range t.Tiles {
+			ts.tiles[tile.ID] = tile.Properties.toMap()
+		}
+		tm.tilesets = append(tm.tilesets, ts)
+	}
+
+	var addLayers func(layers []tmxLayer) error
+	addLayers = func(layers []tmxLayer) error {
+		for _, l := range layers {
+			layer := tiledLayer{name: l.Name, properties: l.Properties.toMap()}
+			switch l.XMLName.Local {
+			case "layer":
+				if len(l.Data.Chunks) > 0 {
+					return errors.New("infinite Tiled maps aren't supported")
+				}
+				if l.Data.Encoding == "" {
+					for _, tile := range l.Data.Tiles {
+						layer.data = append(layer.data, tile.GID)
+					}
+				} else if layer.data, err = decodeTiledData(l.Data.Encoding, l.Data.Compression, l.Data.Text); err != nil {
+					return fmt.Errorf("layer %s: %w", l.Name, err)
+				}
+			case "objectgroup":
+				for _, o := range l.Objects {
+					class := o.Class
+					if class == "" {
+						class = o.Type
+					}
+					layer.objects = append(layer.objects, tiledObject{name: o.Name, class: class, gid: o.GID, x: o.X, y: o.Y, properties: o.Properties.toMap()})
+				}
+			case "group":
+				if err := addLayers(l.Layers); err != nil {
+					return err
+				}
+				continue
+			default:
+				continue
+			}
+			tm.layers = append(tm.layers, layer)
+		}
+		return nil
+	}
+	err = addLayers(t.Layers)
+	return
+}
+
+// loadTiledJSON loads a Tiled map in the JSON format.
+func loadTiledJSON(filename string, r []byte) (tm tiledMap, err error) {
+	var t tiledJSONMap
+	if err = json.Unmarshal(r, &t); err != nil {
+		return
+	}
+	if t.Infinite {
+		return tm, errors.New("infinite Tiled maps aren't supported")
+	}
+	tm.width, tm.height, tm.tileWidth, tm.tileHeight = t.Width, t.Height, t.TileWidth, t.TileHeight
+	tm.properties = tiledJSONPropertiesToMap(t.Properties)
+
+	for _, t := range t.Tilesets {
+		if t.Source != "" {
+			ts, err := loadTiledTileset(filepath.Join(filepath.Dir(filename), t.Source), t.FirstGID)
+			if err != nil {
+				return tm, err
+			}
+			tm.tilesets = append(tm.tilesets, ts)
+			continue
+		}
+		ts := tiledTileset{firstGID: t.FirstGID, name: t.Name, tiles: make(map[int]map[string]string)}
+		for _, tile := range t.Tiles {
+			ts.tiles[tile.ID] = tiledJSONPropertiesToMap(tile.Properties)
+		}
+		tm.tilesets = append(tm.tilesets, ts)
+	}
+
+	var addLayers func(layers []tiledJSONLayer) error
+	addLayers = func(layers []tiledJSONLayer) error {
+		for _, l := range layers {
+			layer := tiledLayer{name: l.Name, properties: tiledJSONPropertiesToMap(l.Properties)}
+			switch l.Type {
+			case "tilelayer":
+				if len(l.Chunks) > 0 {
+					return errors.New("infinite Tiled maps aren't supported")
+				}
+				if l.Encoding == "base64" {
+					var text string
+					if err := json.Unmarshal(l.Data, &text); err != nil {
+						return fmt.Errorf("layer %s: %w", l.Name, err)
+					}
+					if layer.data, err = decodeTiledData(l.Encoding, l.Compression, text); err != nil {
+						return fmt.Errorf("layer %s: %w", l.Name, err)
+					}
+				} else if err := json.Unmarshal(l.Data, &layer.data); err != nil {
+					return fmt.Errorf("layer %s: %w", l.Name, err)
+				}
+			case "objectgroup":
+				for _, o := range l.Objects {
+					class := o.Class
+					if class == "" {
+						class = o.Type
+					}
+					layer.objects = append(layer.objects, tiledObject{name: o.Name, class: class, gid: o.GID, x: o.X, y: o.Y, properties: tiledJSONPropertiesToMap(o.Properties)})
+				}
+			case "group":
+				if err := addLayers(l.Layers); err != nil {
+					return err
+				}
+				continue
+			default:
+				continue
+			}
+			tm.layers = append(tm.layers, layer)
+		}
+		return nil
+	}
+	err = addLayers(t.Layers)
+	return
+}
+
+// getTiledArch returns the archetype for a global tile ID.
+func (tm *tiledMap) getTiledArch(gid uint32, mapping TiledMapping) (string, error) {
+	gid &= tiledGIDMask
+	var ts *tiledTileset
+	for i := range tm.tilesets {
+		if tm.tilesets[i].firstGID <= int(gid) && (ts == nil || tm.tilesets[i].firstGID > ts.firstGID) {
+			ts = &tm.tilesets[i]
+		}
+	}
+	if ts == nil {
+		return "", fmt.Errorf("tile %d has no tileset", gid)
+	}
+	id := int(gid) - ts.firstGID
+	if arch, ok := mapping.Tilesets[ts.name][id]; ok {
+		return arch, nil
+	}
+	if arch := ts.tiles[id]["arch"]; arch != "" {
+		return arch, nil
+	}
+	return "", fmt.Errorf("tile %d of tileset %s has no archetype", id, ts.name)
+}
+
+// ImportTiled converts a Tiled TMX or JSON map into a map. Each tile layer becomes a Y level, or, if stacking, is placed on top of the previous layers. Archetypes that couldn't be mapped are skipped and reported as warnings.
+func (m *Manager) ImportTiled(filename string, opts TiledImportOptions) (sm *sdata.Map, warnings []string, err error) {
+	r, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	var tm tiledMap
+	if strings.HasSuffix(filename, ".tmx") {
+		tm, err = loadTiledTMX(filename, r)
+	} else {
+		tm, err = loadTiledJSON(filename, r)
+	}
+	if err != nil {
+		return
+	}
+	if tm.width <= 0 || tm.height <= 0 || tm.tileWidth <= 0 || tm.tileHeight <= 0 {
+		return nil, nil, errors.New("the Tiled map has no size")
+	}
+
+	// Work out the Y level of each layer.
+	levels := make([]int, len(tm.layers))
+	height := 1
+	if h, err := strconv.Atoi(tm.properties["height"]); err == nil && h > height {
+		height = h
+	}
+	for i, l := range tm.layers {
+		if y, err := strconv.Atoi(l.properties["y"]); err == nil {
+			levels[i] = y
+		} else if y, ok := opts.Mapping.Layers[l.name]; ok {
+			levels[i] = y
+		} else if !opts.Stack {
+			levels[i] = i
+		}
+		if levels[i] < 0 {
+			return nil, nil, fmt.Errorf("layer %s has a negative Y level", l.name)
+		}
+		if levels[i] >= height {
+			height = levels[i] + 1
+		}
+	}
+
+	sm = &sdata.Map{
+		Name:   tm.properties["name"],
+		Height: height,
+		Width:  tm.width,
+		Depth:  tm.height,
+	}
+	if sm.Name == "" {
+		sm.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	sm.Tiles = make([][][][]sdata.Archetype, sm.Height)
+	for y := range sm.Tiles {
+		sm.Tiles[y] = make([][][]sdata.Archetype, sm.Width)
+		for x := range sm.Tiles[y] {
+			sm.Tiles[y][x] = make([][]sdata.Archetype, sm.Depth)
+			for z := range sm.Tiles[y][x] {
+				sm.Tiles[y][x][z] = []sdata.Archetype{}
+			}
+		}
+	}
+
+	missing := make(map[string]struct{})
+	warn := func(err error) {
+		missing[err.Error()] = struct{}{}
+	}
+	for i, l := range tm.layers {
+		y := levels[i]
+		for j, gid := range l.data {
+			if gid&tiledGIDMask == 0 {
+				continue
+			}
+			x, z := j%tm.width, j/tm.width
+			if z >= sm.Depth {
+				break
+			}
+			arch, err := tm.getTiledArch(gid, opts.Mapping)
+			if err != nil {
+				warn(err)
+				continue
+			}
+			sm.Tiles[y][x][z] = append(sm.Tiles[y][x][z], sdata.Archetype{Archs: []string{arch}})
+		}
+		for _, o := range l.objects {
+			var arch string
+			if o.gid != 0 {
+				a, err := tm.getTiledArch(o.gid, opts.Mapping)
+				if err != nil {
+					warn(err)
+					continue
+				}
+				arch = a
+			} else if a, ok := opts.Mapping.Objects[o.class]; ok && o.class != "" {
+				arch = a
+			} else if a, ok := opts.Mapping.Objects[o.name]; ok && o.name != "" {
+				arch = a
+			} else if a := o.properties["arch"]; a != "" {
+				arch = a
+			} else {
+				warn(fmt.Errorf("object %s of class %s has no archetype", o.name, o.class))
+				continue
+			}
+			// Tile objects are positioned by their bottom-left corner.
+			oy := o.y
+			if o.gid != 0 {
+				oy -= float64(tm.tileHeight)
+			}
+			x, z := int(math.Floor(o.x/float64(tm.tileWidth))), int(math.Floor(oy/float64(tm.tileHeight)))
+			if x < 0 || z < 0 || x >= sm.Width || z >= sm.Depth {
+				warn(fmt.Errorf("object %s is outside of the map", o.name))
+				continue
+			}
+			a := sdata.Archetype{Archs: []string{arch}}
+			if o.name != "" && o.name != arch {
+				name := o.name
+				a.Name = &name
+			}
+			sm.Tiles[y][x][z] = append(sm.Tiles[y][x][z], a)
+		}
+	}
+	for w := range missing {
+		warnings = append(warnings, w)
+	}
+	sort.Strings(warnings)
+	return
+}
+
+// ExportTiled writes the map as a Tiled JSON map. Each Y level becomes one tile layer per stack position, and the archetypes' images under the ArchetypesPath become an image collection tileset whose tiles carry their archetype in an "arch" property. Only archetype names are exported, not per-tile changes to them.
+func (m *Manager) ExportTiled(filename string, sm *sdata.Map) error {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+
+	// Gather the archetypes used by the map as tiles.
+	tileset := tiledJSONTileset{
+		FirstGID: 1,
+		Name:     "archetypes",
+	}
+	gids := make(map[string]uint32)
+	getGID := func(a sdata.Archetype) uint32 {
+		name := getArchName(a)
+		if gid, ok := gids[name]; ok {
+			return gid
+		}
+		tile := tiledJSONTile{
+			ID:         len(tileset.Tiles),
+			Properties: []tiledJSONProperty{{Name: "arch", Type: "string", Value: name}},
+		}
+		anim, face := m.GetAnimAndFace(&a, "", "")
+		if imageName, err := m.GetAnimFaceImage(anim, face); err == nil {
+			if rel, err := filepath.Rel(dir, filepath.Join(m.ArchetypesPath, imageName)); err == nil {
+				tile.Image = filepath.ToSlash(rel)
+			}
+			if img := m.GetImage(imageName); img != nil {
+				tile.ImageWidth, tile.ImageHeight = img.Bounds().Dx(), img.Bounds().Dy()
+				if tile.ImageWidth > tileset.TileWidth {
+					tileset.TileWidth = tile.ImageWidth
+				}
+				if tile.ImageHeight > tileset.TileHeight {
+					tileset.TileHeight = tile.ImageHeight
+				}
+			}
+		}
+		tileset.Tiles = append(tileset.Tiles, tile)
+		gids[name] = uint32(tile.ID + 1)
+		return gids[name]
+	}
+
+	t := tiledJSONMap{
+		Type:        "map",
+		Version:     "1.8",
+		Orientation: "orthogonal",
+		RenderOrder: "right-down",
+		Width:       sm.Width,
+		Height:      sm.Depth,
+		TileWidth:   int(m.AnimationsConfig.TileWidth),
+		TileHeight:  int(m.AnimationsConfig.TileHeight),
+		Properties: []tiledJSONProperty{
+			{Name: "name", Type: "string", Value: sm.Name},
+			{Name: "height", Type: "int", Value: sm.Height},
+		},
+		NextObjectID: 1,
+	}
+	for y := 0; y < sm.Height; y++ {
+		stack := 0
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				if tiles := getMapTile(sm, y, x, z); len(tiles) > stack {
+					stack = len(tiles)
+				}
+			}
+		}
+		for i := 0; i < stack; i++ {
+			data := make([]uint32, sm.Width*sm.Depth)
+			for x := 0; x < sm.Width; x++ {
+				for z := 0; z < sm.Depth; z++ {
+					if tiles := getMapTile(sm, y, x, z); i < len(tiles) {
+						data[z*sm.Width+x] = getGID(tiles[i])
+					}
+				}
+			}
+			raw, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			name := fmt.Sprintf("y %d", y)
+			if i > 0 {
+				name = fmt.Sprintf("y %d (%d)", y, i+1)
+			}
+			t.Layers = append(t.Layers, tiledJSONLayer{
+				ID:         len(t.Layers) + 1,
+				Name:       name,
+				Type:       "tilelayer",
+				Width:      sm.Width,
+				Height:     sm.Depth,
+				Opacity:    1,
+				Visible:    true,
+				Data:       raw,
+				Properties: []tiledJSONProperty{{Name: "y", Type: "int", Value: y}},
+			})
+		}
+	}
+	t.NextLayerID = len(t.Layers) + 1
+	if tileset.TileWidth == 0 || tileset.TileHeight == 0 {
+		tileset.TileWidth, tileset.TileHeight = t.TileWidth, t.TileHeight
+	}
+	tileset.TileCount = len(tileset.Tiles)
+	t.Tilesets = []tiledJSONTileset{tileset}
+
+	out, err := json.MarshalIndent(t, "", " ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, out, 0644)
+}
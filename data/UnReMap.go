@@ -10,6 +10,7 @@ type UnReMap struct {
 	dataName string
 	savedMap *sdata.Map
 	unsaved  bool
+	revision int
 }
 
 func NewUnReMap(m *sdata.Map, d string) *UnReMap {
@@ -24,11 +25,13 @@ func NewUnReMap(m *sdata.Map, d string) *UnReMap {
 }
 
 func (u *UnReMap) Replace(m *sdata.Map) {
+	u.revision++
 	u.undoer.Replace(m)
 }
 
 func (u *UnReMap) Set(m *sdata.Map) {
 	u.unsaved = true
+	u.revision++
 	u.undoer.Push(m)
 }
 
@@ -38,14 +41,21 @@ func (u *UnReMap) Get() *sdata.Map {
 
 func (u *UnReMap) Undo() {
 	u.unsaved = true
+	u.revision++
 	u.undoer.Undo()
 }
 
 func (u *UnReMap) Redo() {
 	u.unsaved = true
+	u.revision++
 	u.undoer.Redo()
 }
 
+// Revision returns a counter that changes whenever the current map state changes.
+func (u *UnReMap) Revision() int {
+	return u.revision
+}
+
 func (u *UnReMap) SavedMap() *sdata.Map {
 	return u.savedMap
 }
@@ -64,6 +74,7 @@ func (u *UnReMap) Save() {
 }
 
 func (u *UnReMap) Reset() {
+	u.revision++
 	u.undoer.Replace(u.savedMap)
 	u.Save()
 	u.unsaved = false
@@ -15,6 +15,7 @@ type Archset struct {
 	archs                []*UnReArch
 	archsSauce           []string
 	shouldClose          bool
+	closeRequested       bool
 	newDataName, newName string
 	currentArchIndex     int
 }
@@ -48,7 +49,9 @@ func (a *Archset) draw() *g.WindowWidget {
 					newArchPopup = true
 				}),
 				g.Separator(),
-				g.MenuItem("Save All").OnClick(func() {}),
+				g.MenuItem("Save All").OnClick(func() {
+					a.saveAll()
+				}),
 				g.Separator(),
 				g.MenuItem("Close").OnClick(func() {
 					a.close()
@@ -121,6 +124,26 @@ func (a *Archset) setDefaults() {
 	a.newDataName = path.Join(path.Dir(a.filename), "myarch")
 }
 
+// close asks for the archset to be closed. The editor confirms first if there are unsaved changes.
 func (a *Archset) close() {
-	a.shouldClose = true
+	a.closeRequested = true
+}
+
+// unsaved returns whether any arch in the archset has unsaved changes.
+func (a *Archset) unsaved() bool {
+	for _, arch := range a.archs {
+		if arch.unsaved {
+			return true
+		}
+	}
+	return false
+}
+
+// saveAll saves every arch in the archset that has unsaved changes.
+func (a *Archset) saveAll() {
+	for _, arch := range a.archs {
+		if arch.unsaved {
+			arch.Save()
+		}
+	}
 }
@@ -0,0 +1,163 @@
+package editor
+
+import (
+	"fmt"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	"github.com/chimera-rpg/go-editor/editor/mapview"
+	log "github.com/sirupsen/logrus"
+)
+
+// DiffView compares two versions of a map file, showing changed properties and overlaying changed tiles on the newer version.
+type DiffView struct {
+	isOpen           bool
+	oldPath, newPath string
+	diffs            []data.MapDiff
+	err              error
+	selected         int
+	target           *mapview.Mapset // Mapset the tile changes are overlaid on.
+}
+
+func NewDiffView() *DiffView {
+	return &DiffView{}
+}
+
+// compareDiff loads both versions of the map file, opens the newer one, and overlays the changes on it.
+func (e *Editor) compareDiff() {
+	d := e.diff
+	d.clear()
+	oldMaps, err := e.context.dataManager.LoadMap(d.oldPath)
+	if err != nil {
+		d.err = err
+		return
+	}
+	newMaps, err := e.context.dataManager.LoadMap(d.newPath)
+	if err != nil {
+		d.err = err
+		return
+	}
+	d.diffs = data.DiffMaps(oldMaps, newMaps)
+
+	m, _ := e.findOpenMap(d.newPath, "")
+	if m == nil {
+		if err := e.openMap(d.newPath); err != nil {
+			d.err = err
+			return
+		}
+		m, _ = e.findOpenMap(d.newPath, "")
+	}
+	if m != nil {
+		m.ShowDiff(d.diffs)
+		d.target = m
+	}
+}
+
+// useLatestBackup compares the newer map file against its most recent backup.
+func (e *Editor) useLatestBackup() {
+	d := e.diff
+	backups, err := e.context.dataManager.GetMapBackups(d.newPath)
+	if err != nil {
+		log.Errorln(err)
+	}
+	if len(backups) == 0 {
+		d.err = fmt.Errorf("%s has no backups", d.newPath)
+		return
+	}
+	d.oldPath = backups[0].Path
+	e.compareDiff()
+}
+
+// clear removes the current diff and its overlay.
+func (d *DiffView) clear() {
+	if d.target != nil {
+		d.target.ShowDiff(nil)
+	}
+	d.diffs = nil
+	d.err = nil
+	d.selected = 0
+	d.target = nil
+}
+
+// focusTileChange switches the diffed mapset to the given map and tile.
+func (d *DiffView) focusTileChange(dataName string, t data.TileChange) {
+	if d.target == nil || !d.target.SelectMap(dataName) {
+		return
+	}
+	d.target.FocusTile(t.Y, t.X, t.Z)
+}
+
+func (e *Editor) drawDiff() (title string, win *g.WindowWidget, layout g.Layout) {
+	d := e.diff
+	title = "Diff"
+	win = g.Window(title)
+	win.IsOpen(&d.isOpen).Pos(240, 50).Size(600, 400)
+
+	var mapItems g.Layout
+	for i, md := range d.diffs {
+		func(i int, md data.MapDiff) {
+			label := md.Map
+			switch {
+			case md.Added:
+				label += " (added)"
+			case md.Removed:
+				label += " (removed)"
+			default:
+				label += fmt.Sprintf(" (%d properties, %d tiles)", len(md.Properties), len(md.Tiles))
+			}
+			mapItems = append(mapItems, g.Selectable(label).Selected(i == d.selected).OnClick(func() {
+				d.selected = i
+				if d.target != nil {
+					d.target.SelectMap(md.Map)
+				}
+			}))
+		}(i, md)
+	}
+
+	var changeItems g.Layout
+	if d.selected < len(d.diffs) {
+		md := d.diffs[d.selected]
+		for _, p := range md.Properties {
+			changeItems = append(changeItems, g.Label(fmt.Sprintf("%s: %v -> %v", p.Name, p.Old, p.New)))
+		}
+		for _, t := range md.Tiles {
+			func(t data.TileChange) {
+				label := fmt.Sprintf("%dx%dx%d %s: %s -> %s", t.X, t.Z, t.Y, data.TileChangeNames[t.Kind], data.DescribeTile(t.Old, ", "), data.DescribeTile(t.New, ", "))
+				changeItems = append(changeItems, g.Selectable(label).OnClick(func() {
+					d.focusTileChange(md.Map, t)
+				}))
+			}(t)
+		}
+	}
+
+	var status g.Widget
+	if d.err != nil {
+		status = g.Label(d.err.Error())
+	} else if d.target != nil && len(d.diffs) == 0 {
+		status = g.Label("No differences")
+	} else {
+		status = g.Dummy(0, 0)
+	}
+
+	layout = g.Layout{
+		g.InputText(&d.oldPath).Label("Old"),
+		g.InputText(&d.newPath).Label("New"),
+		g.Row(
+			g.Button("Compare").OnClick(func() {
+				e.compareDiff()
+			}),
+			g.Button("Compare With Backup").OnClick(func() {
+				e.useLatestBackup()
+			}),
+			g.Button("Clear").OnClick(func() {
+				d.clear()
+			}),
+		),
+		status,
+		g.SplitLayout(g.DirectionHorizontal, true, 200,
+			g.Layout{mapItems},
+			g.Layout{changeItems},
+		),
+	}
+	return
+}
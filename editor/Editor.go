@@ -6,6 +6,7 @@ import (
 	"image/draw"
 	"os"
 	"path"
+	"time"
 
 	_ "embed"
 
@@ -31,12 +32,20 @@ type Editor struct {
 	mapsets        []*mapview.Mapset
 	archsets       []*Archset
 	animsets       []*Animset
+	world          *World
+	links          *LinkGraph
+	diff           *DiffView
 	context        Context
 	taskbar        *widgets.TaskbarWidget
 	//
 	pendingImages map[string]image.Image
 	//
 	openMapCWD, openMapFilename string
+	lastAutosave                time.Time
+	unsaved                     unsavedPrompt
+	unsavedQueue                []unsavedPrompt // Prompts requested while another was still open.
+	merge                       mergeView
+	exitRequested               int32 // Set by RequestExit, possibly from another goroutine.
 }
 
 func (e *Editor) Setup(dataManager *data.Manager) (err error) {
@@ -52,6 +61,9 @@ func (e *Editor) Setup(dataManager *data.Manager) (err error) {
 	e.showSplash = false
 	e.openMapCWD = dataManager.MapsPath
 	e.taskbar = widgets.NewTaskbar()
+	e.world = NewWorld()
+	e.links = NewLinkGraph()
+	e.diff = NewDiffView()
 
 	e.masterWindow = g.NewMasterWindow("Editor", 1280, 720, g.MasterWindowFlagsMaximized)
 	g.Context.GetRenderer().SetTextureMagFilter(g.TextureFilterNearest)
@@ -83,7 +95,10 @@ func (e *Editor) Start() {
 
 func (e *Editor) loop() {
 	if !e.isRunning {
-		os.Exit(0)
+		if err := e.context.dataManager.EditorConfig.Save(); err != nil {
+			log.Errorln(err)
+		}
+		os.Exit(e.ExitCode())
 	}
 
 	var openMapPopup bool
@@ -116,9 +131,40 @@ func (e *Editor) loop() {
 	}
 
 	if !e.isRestored {
+		// A merge only opens the merged map file.
+		if e.merge.filename != "" {
+			if err := e.openMap(e.merge.filename); err != nil {
+				log.Errorln(err)
+			}
+			if len(e.merge.conflicts) > 0 {
+				e.focusMergeConflict(e.merge.conflicts[0])
+			}
+			e.lastAutosave = time.Now()
+			e.isRestored = true
+			return
+		}
 		for _, mapName := range e.context.dataManager.EditorConfig.OpenMaps {
-			e.openMap(mapName)
+			if err := e.openMap(mapName); err != nil {
+				log.Errorln(err)
+				continue
+			}
+			maps, err := e.context.dataManager.LoadRecovery(mapName)
+			if err != nil {
+				log.Errorln(err)
+			} else if m, _ := e.findOpenMap(mapName, ""); m != nil {
+				m.OfferRecovery(maps)
+			}
 		}
+		recoveries, err := e.context.dataManager.LoadUntitledRecoveries()
+		if err != nil {
+			log.Errorln(err)
+		}
+		for name, maps := range recoveries {
+			m := mapview.NewMapset(&e.context, "", nil)
+			m.OfferUntitledRecovery(name, maps)
+			e.mapsets = append(e.mapsets, m)
+		}
+		e.lastAutosave = time.Now()
 		e.isRestored = true
 		return
 	}
@@ -131,8 +177,19 @@ func (e *Editor) loop() {
 			g.MenuItem("Open Mapset...").OnClick(func() {
 				openMapPopup = true
 			}),
+			g.MenuItem("World Atlas").OnClick(func() {
+				e.world.isOpen = true
+				e.world.isLoaded = false
+			}),
+			g.MenuItem("Map Links").OnClick(func() {
+				e.links.isOpen = true
+				e.world.isLoaded = false
+			}),
+			g.MenuItem("Diff Mapsets").OnClick(func() {
+				e.diff.isOpen = true
+			}),
 			g.Separator(),
-			g.MenuItem("Exit").OnClick(func() { e.isRunning = false }),
+			g.MenuItem("Exit").OnClick(func() { e.exit() }),
 		),
 		g.Menu("Misc").Layout(
 			g.Button("Button"),
@@ -181,13 +238,65 @@ func (e *Editor) loop() {
 			window: win,
 			layout: layout,
 		})
+		if m.CloseRequested {
+			m.CloseRequested = false
+			func(m *mapview.Mapset) {
+				e.confirmUnsaved([]*mapview.Mapset{m}, nil, func() {
+					m.ShouldClose = true
+				})
+			}(m)
+		}
 		if m.ShouldClose {
 			e.mapsets = append(e.mapsets[:i], e.mapsets[i+1:]...)
 			e.context.dataManager.EditorConfig.RemoveMap(m.Filepath())
 		}
 	}
 
+	if e.world.isOpen {
+		title, win, layout := e.drawWorld()
+		windows = append(windows, &WindowContainer{
+			title:  title,
+			window: win,
+			layout: layout,
+		})
+	}
+
+	if e.links.isOpen {
+		title, win, layout := e.drawLinkGraph()
+		windows = append(windows, &WindowContainer{
+			title:  title,
+			window: win,
+			layout: layout,
+		})
+	}
+
+	if e.diff.isOpen {
+		title, win, layout := e.drawDiff()
+		windows = append(windows, &WindowContainer{
+			title:  title,
+			window: win,
+			layout: layout,
+		})
+	}
+
+	if e.merge.isOpen {
+		title, win, layout := e.drawMergeConflicts()
+		windows = append(windows, &WindowContainer{
+			title:  title,
+			window: win,
+			layout: layout,
+		})
+	}
+
 	for i, a := range e.archsets {
+		if a.closeRequested {
+			a.closeRequested = false
+			func(a *Archset) {
+				e.confirmUnsaved(nil, []*Archset{a}, func() {
+					a.shouldClose = true
+				})
+			}(a)
+		}
 		if a.shouldClose {
 			e.archsets = append(e.archsets[:i], e.archsets[i+1:]...)
 		}
@@ -206,6 +315,9 @@ func (e *Editor) loop() {
 
 	e.drawAnimations()
 	e.drawSplash()
+	e.autosave()
+	e.handleExitRequest()
+	e.drawUnsavedPrompt()
 
 	w, h := e.masterWindow.GetSize()
 	e.taskbar.ParentWidth = w
@@ -329,14 +441,17 @@ func (e *Editor) drawArchetypes() (title string, w *g.WindowWidget, layout g.Lay
 				g.Checkbox("Archetype Mode", &e.archetypesMode),
 				g.Button("Reload Archetypes").OnClick(func() {
 					e.context.dataManager.ReloadArchetypes()
+					e.invalidateMapsets()
 				}),
 				g.Button("Reload Animations").OnClick(func() {
 					e.context.dataManager.ReloadAnimations()
+					e.invalidateMapsets()
 				}),
 				g.Button("Reload Images").OnClick(func() {
 					e.context.dataManager.ReloadImages()
 					e.pendingImages = e.context.dataManager.GetImages()
 					e.isLoaded = false
+					e.invalidateMapsets()
 				}),
 			),
 		),
@@ -346,6 +461,26 @@ func (e *Editor) drawArchetypes() (title string, w *g.WindowWidget, layout g.Lay
 	return
 }
 
+// autosave writes the unsaved maps of every mapset to their recovery files once the autosave interval has passed.
+func (e *Editor) autosave() {
+	interval := e.context.dataManager.GetAutosaveInterval()
+	if interval == 0 || time.Since(e.lastAutosave) < interval {
+		return
+	}
+	e.lastAutosave = time.Now()
+	for _, m := range e.mapsets {
+		if err := m.Autosave(); err != nil {
+			log.Errorln(err)
+		}
+	}
+}
+
+func (e *Editor) invalidateMapsets() {
+	for _, m := range e.mapsets {
+		m.InvalidateDrawCache()
+	}
+}
+
 func (e *Editor) drawAnimations() {
 	var rows []*g.RowWidget
 	rows = append(rows, g.Row(g.Label("anim")))
@@ -0,0 +1,320 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	g "github.com/AllenDang/giu"
+	imgui "github.com/AllenDang/imgui-go"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+var linkColor = color.RGBA{160, 160, 160, 255}
+var linkOneWayColor = color.RGBA{255, 192, 0, 255}
+var linkBrokenColor = color.RGBA{255, 0, 0, 255}
+var linkNodeColor = color.RGBA{48, 48, 64, 255}
+var linkSelectedColor = color.RGBA{255, 255, 0, 255}
+
+// Problems a map link can have, from least to most severe.
+const (
+	linkOK = iota
+	linkOneWay
+	linkOutOfBounds
+	linkMissing
+)
+
+var linkProblemNames = []string{"", "one-way", "out of bounds", "missing map"}
+
+// mapLink is an exit archetype placed in a map and where it leads.
+type mapLink struct {
+	from    *worldEntry
+	to      *worldEntry // Map the exit leads to, or nil if it is missing.
+	y, x, z int
+	exit    data.ArchExit
+	problem int
+}
+
+// LinkGraph shows the exit links between every map under the MapsPath as a directed graph.
+type LinkGraph struct {
+	isOpen   bool
+	scanned  map[*worldEntry]*sdata.Map // Map states the links were scanned from.
+	links    []mapLink
+	nodes    []string // Node names, including missing targets.
+	selected string
+	onlyBad  bool
+}
+
+func NewLinkGraph() *LinkGraph {
+	return &LinkGraph{}
+}
+
+// isLinkGraphStale returns whether any map has changed since the links were last scanned.
+func (e *Editor) isLinkGraphStale() bool {
+	l := e.links
+	if l.scanned == nil || len(l.scanned) != len(e.world.entries) {
+		return true
+	}
+	for _, entry := range e.world.entries {
+		if l.scanned[entry] != e.getWorldMap(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanLinks finds every exit in every map of the world and checks where they lead.
+func (e *Editor) scanLinks() {
+	dm := e.context.dataManager
+	l := e.links
+	l.links = nil
+	l.scanned = make(map[*worldEntry]*sdata.Map)
+
+	byName := make(map[string][]*worldEntry)
+	for _, entry := range e.world.entries {
+		sm := e.getWorldMap(entry)
+		l.scanned[entry] = sm
+		byName[entry.dataName] = append(byName[entry.dataName], entry)
+		for y := range sm.Tiles {
+			for x := range sm.Tiles[y] {
+				for z := range sm.Tiles[y][x] {
+					for t := range sm.Tiles[y][x][z] {
+						if exit, ok := dm.GetArchExit(&sm.Tiles[y][x][z][t]); ok {
+							l.links = append(l.links, mapLink{
+								from: entry,
+								y:    y,
+								x:    x,
+								z:    z,
+								exit: exit,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Resolve each exit's target, preferring a map in the same mapset file when several share the data name.
+	for i, link := range l.links {
+		for _, entry := range byName[link.exit.Map] {
+			if l.links[i].to == nil || entry.filename == link.from.filename {
+				l.links[i].to = entry
+			}
+		}
+	}
+
+	// Note which maps lead to which, so one-way links can be found.
+	leadsTo := make(map[[2]*worldEntry]bool)
+	for _, link := range l.links {
+		if link.to != nil {
+			leadsTo[[2]*worldEntry{link.from, link.to}] = true
+		}
+	}
+
+	nodes := make(map[string]bool)
+	for _, entry := range e.world.entries {
+		nodes[entry.dataName] = true
+	}
+	for i, link := range l.links {
+		if link.to == nil {
+			l.links[i].problem = linkMissing
+			nodes[link.exit.Map] = true
+			continue
+		}
+		target := l.scanned[link.to]
+		if link.exit.Y < 0 || link.exit.Y >= target.Height || link.exit.X < 0 || link.exit.X >= target.Width || link.exit.Z < 0 || link.exit.Z >= target.Depth {
+			l.links[i].problem = linkOutOfBounds
+		} else if link.to != link.from && !leadsTo[[2]*worldEntry{link.to, link.from}] {
+			l.links[i].problem = linkOneWay
+		}
+	}
+	l.nodes = nil
+	for name := range nodes {
+		l.nodes = append(l.nodes, name)
+	}
+	sort.Strings(l.nodes)
+}
+
+// openLink opens the map containing the link and moves the cursor to the exit.
+func (e *Editor) openLink(link mapLink) {
+	if v := e.openWorldEntry(link.from); v == nil {
+		return
+	}
+	if m, _ := e.findOpenMap(link.from.filename, link.from.dataName); m != nil {
+		m.FocusTile(link.y, link.x, link.z)
+	}
+}
+
+// openLinkNode opens the map with the given name, if it exists.
+func (e *Editor) openLinkNode(name string) {
+	if entry := e.world.findEntry(name); entry != nil {
+		e.openWorldEntry(entry)
+	}
+}
+
+func (e *Editor) drawLinkGraph() (title string, win *g.WindowWidget, layout g.Layout) {
+	l := e.links
+	title = "Map Links"
+	win = g.Window(title)
+	win.IsOpen(&l.isOpen).Flags(g.WindowFlagsMenuBar).Pos(240, 50).Size(700, 500)
+
+	if !e.world.isLoaded {
+		e.reloadWorld()
+	}
+	if e.isLinkGraphStale() {
+		e.scanLinks()
+	}
+
+	// Lay the nodes out in a circle.
+	nodeW, nodeH := 0, int(imgui.CalcTextSize("Map", false, 0).Y)+4
+	for _, name := range l.nodes {
+		if w := int(imgui.CalcTextSize(name, false, 0).X) + 8; w > nodeW {
+			nodeW = w
+		}
+	}
+	radius := math.Max(100, float64(len(l.nodes)*(nodeH+8))/math.Pi)
+	size := int(radius*2) + nodeW*2
+	nodePos := make(map[string]image.Point)
+	for i, name := range l.nodes {
+		angle := 2 * math.Pi * float64(i) / float64(len(l.nodes))
+		nodePos[name] = image.Pt(size/2+int(math.Cos(angle)*radius), size/2+int(math.Sin(angle)*radius))
+	}
+
+	// Combine links between the same maps, keeping the worst problem.
+	type edge struct{ from, to string }
+	edges := make(map[edge]int)
+	for _, link := range l.links {
+		k := edge{link.from.dataName, link.exit.Map}
+		if p, ok := edges[k]; !ok || link.problem > p {
+			edges[k] = link.problem
+		}
+	}
+
+	var problems int
+	var linkItems g.Layout
+	for _, link := range l.links {
+		if link.problem != linkOK {
+			problems++
+		}
+		if l.onlyBad && link.problem == linkOK {
+			continue
+		}
+		if l.selected != "" && link.from.dataName != l.selected && link.exit.Map != l.selected {
+			continue
+		}
+		label := fmt.Sprintf("%s %dx%dx%d -> %s %dx%dx%d", link.from.dataName, link.x, link.z, link.y, link.exit.Map, link.exit.X, link.exit.Z, link.exit.Y)
+		if link.problem != linkOK {
+			label += fmt.Sprintf(" (%s)", linkProblemNames[link.problem])
+		}
+		func(link mapLink) {
+			linkItems = append(linkItems, g.Selectable(label).OnClick(func() {
+				e.openLink(link)
+			}))
+		}(link)
+	}
+
+	var canvasPos image.Point
+	layout = g.Layout{
+		g.MenuBar().Layout(
+			g.Menu("Links").Layout(
+				g.MenuItem("Rescan").OnClick(func() {
+					e.reloadWorld()
+					e.scanLinks()
+				}),
+				g.Separator(),
+				g.Checkbox("Only Problems", &l.onlyBad),
+			),
+		),
+		g.Custom(func() {
+			availW, availH := g.GetAvailableRegion()
+			g.SplitLayout(g.DirectionHorizontal, true, availW*2/3,
+				g.Child().Border(false).Flags(g.WindowFlagsHorizontalScrollbar).Size(g.Auto, availH).Layout(
+					g.Custom(func() {
+						canvasPos = g.GetCursorScreenPos()
+						canvas := g.GetCanvas()
+						center := func(name string) image.Point {
+							return canvasPos.Add(nodePos[name])
+						}
+						for k, problem := range edges {
+							if l.onlyBad && problem == linkOK {
+								continue
+							}
+							col := linkColor
+							if problem == linkOneWay {
+								col = linkOneWayColor
+							} else if problem != linkOK {
+								col = linkBrokenColor
+							}
+							p1, p2 := center(k.from), center(k.to)
+							if k.from == k.to {
+								canvas.AddRect(p1.Sub(image.Pt(nodeW/2+6, nodeH/2+6)), p1.Add(image.Pt(nodeW/2+6, nodeH/2+6)), col, 0, 0, 1)
+								continue
+							}
+							canvas.AddLine(p1, p2, col, 1)
+							// Draw an arrowhead just outside of the target node.
+							dx, dy := float64(p2.X-p1.X), float64(p2.Y-p1.Y)
+							dist := math.Hypot(dx, dy)
+							if dist == 0 {
+								continue
+							}
+							dx, dy = dx/dist, dy/dist
+							tip := image.Pt(p2.X-int(dx*float64(nodeH)), p2.Y-int(dy*float64(nodeH)))
+							for _, side := range []float64{-1, 1} {
+								canvas.AddLine(tip, image.Pt(tip.X-int((dx+dy*side*0.5)*8), tip.Y-int((dy-dx*side*0.5)*8)), col, 1)
+							}
+						}
+						for _, name := range l.nodes {
+							p := center(name)
+							p1, p2 := p.Sub(image.Pt(nodeW/2, nodeH/2)), p.Add(image.Pt(nodeW/2, nodeH/2))
+							canvas.AddRectFilled(p1, p2, linkNodeColor, 0, 0)
+							border := linkColor
+							if name == l.selected {
+								border = linkSelectedColor
+							}
+							if e.world.findEntry(name) == nil {
+								border = linkBrokenColor
+							}
+							canvas.AddRect(p1, p2, border, 0, 0, 1)
+							canvas.AddText(p1.Add(image.Pt(4, 2)), color.RGBA{255, 255, 255, 255}, name)
+						}
+						g.Dummy(float32(size), float32(size)).Build()
+					}),
+					g.Custom(func() {
+						if !g.IsItemHovered() {
+							return
+						}
+						mousePos := g.GetMousePos().Sub(canvasPos)
+						for _, name := range l.nodes {
+							p := nodePos[name]
+							if !mousePos.In(image.Rect(p.X-nodeW/2, p.Y-nodeH/2, p.X+nodeW/2, p.Y+nodeH/2)) {
+								continue
+							}
+							g.Tooltip(name).Build()
+							if g.IsMouseDoubleClicked(g.MouseButtonLeft) {
+								e.openLinkNode(name)
+							} else if g.IsMouseClicked(g.MouseButtonLeft) {
+								if l.selected == name {
+									l.selected = ""
+								} else {
+									l.selected = name
+								}
+							}
+							return
+						}
+						if g.IsMouseClicked(g.MouseButtonLeft) {
+							l.selected = ""
+						}
+					}),
+				),
+				g.Layout{
+					g.Label(fmt.Sprintf("%d maps, %d links, %d problems", len(e.world.entries), len(l.links), problems)),
+					g.Child().Border(false).Size(g.Auto, g.Auto).Layout(linkItems),
+				},
+			).Build()
+		}),
+	}
+	return
+}
@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"fmt"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+	log "github.com/sirupsen/logrus"
+)
+
+// mergeView lists the conflicts left by a three-way merge of a map file and lets each be resolved to the base, our, or their side.
+type mergeView struct {
+	isOpen    bool
+	filename  string
+	conflicts []data.MergeConflict
+	resolved  []int // Side each conflict was resolved to, or -1.
+	selected  int
+}
+
+// ShowMergeConflicts opens the merged map file once the editor starts and shows its conflicts for resolution. The editor exits with a non-zero status unless every conflict is resolved and the mapset saved.
+func (e *Editor) ShowMergeConflicts(filename string, conflicts []data.MergeConflict) {
+	e.merge = mergeView{
+		isOpen:    true,
+		filename:  filename,
+		conflicts: conflicts,
+		resolved:  make([]int, len(conflicts)),
+	}
+	for i := range e.merge.resolved {
+		e.merge.resolved[i] = -1
+	}
+}
+
+// ExitCode returns the status the editor should exit with.
+func (e *Editor) ExitCode() int {
+	if e.merge.filename == "" {
+		return 0
+	}
+	for _, side := range e.merge.resolved {
+		if side == -1 {
+			return 1
+		}
+	}
+	if m, _ := e.findOpenMap(e.merge.filename, ""); m != nil && m.Unsaved() {
+		return 1
+	}
+	return 0
+}
+
+// focusMergeConflict switches to the map and tile of the given conflict.
+func (e *Editor) focusMergeConflict(c data.MergeConflict) {
+	m, _ := e.findOpenMap(e.merge.filename, "")
+	if m == nil || !m.SelectMap(c.Map) {
+		return
+	}
+	if c.Property == "Tile" {
+		m.FocusTile(c.Y, c.X, c.Z)
+	}
+}
+
+// resolveMergeConflict applies the given side of the conflict to the open mapset as an undoable change.
+func (e *Editor) resolveMergeConflict(index, side int) {
+	c := e.merge.conflicts[index]
+	m, v := e.findOpenMap(e.merge.filename, c.Map)
+	if m == nil {
+		return
+	}
+	if c.Property == "" || c.Property == "Size" {
+		if sm, _ := c.Values[side].(*sdata.Map); sm != nil {
+			m.SetMap(c.Map, sm)
+		} else {
+			m.RemoveMap(c.Map)
+		}
+	} else {
+		if v == nil {
+			log.Errorf("map %s is no longer in the mapset", c.Map)
+			return
+		}
+		clone := v.Clone()
+		data.ResolveMergeConflict(clone, c, side)
+		v.Set(clone)
+	}
+	e.merge.resolved[index] = side
+}
+
+// describeMergeValue returns a short description of one side of a conflict.
+func describeMergeValue(c data.MergeConflict, side int) string {
+	switch v := c.Values[side].(type) {
+	case []sdata.Archetype:
+		return data.DescribeTile(v, "\n")
+	case *sdata.Map:
+		if v == nil {
+			return "(deleted)"
+		}
+		return fmt.Sprintf("%s %dx%dx%d", v.Name, v.Width, v.Depth, v.Height)
+	case nil:
+		return "(none)"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (e *Editor) drawMergeConflicts() (title string, win *g.WindowWidget, layout g.Layout) {
+	mv := &e.merge
+	title = "Merge Conflicts"
+	win = g.Window(title)
+	win.IsOpen(&mv.isOpen).Pos(240, 50).Size(500, 300)
+
+	remaining := 0
+	var items g.Layout
+	for i, c := range mv.conflicts {
+		func(i int, c data.MergeConflict) {
+			var label string
+			switch c.Property {
+			case "":
+				label = fmt.Sprintf("%s: deleted and changed", c.Map)
+			case "Size":
+				label = fmt.Sprintf("%s: resized and changed", c.Map)
+			case "Tile":
+				label = fmt.Sprintf("%s: tile %dx%dx%d", c.Map, c.X, c.Z, c.Y)
+			default:
+				label = fmt.Sprintf("%s: %s", c.Map, c.Property)
+			}
+			if mv.resolved[i] == -1 {
+				remaining++
+			} else {
+				label += fmt.Sprintf(" (%s)", data.MergeSideNames[mv.resolved[i]])
+			}
+			items = append(items, g.Selectable(label).Selected(i == mv.selected).OnClick(func() {
+				mv.selected = i
+				e.focusMergeConflict(c)
+			}))
+		}(i, c)
+	}
+
+	var details g.Layout
+	if mv.selected >= 0 && mv.selected < len(mv.conflicts) {
+		c := mv.conflicts[mv.selected]
+		var columns []g.Widget
+		for side := range data.MergeSideNames {
+			func(side int) {
+				columns = append(columns, g.Column(
+					g.Button(fmt.Sprintf("Use %s", data.MergeSideNames[side])).OnClick(func() {
+						e.resolveMergeConflict(mv.selected, side)
+					}),
+					g.Label(describeMergeValue(c, side)),
+				))
+			}(side)
+		}
+		details = g.Layout{g.Row(columns...)}
+	}
+
+	layout = g.Layout{
+		g.Label(fmt.Sprintf("%s: %d of %d conflicts unresolved", mv.filename, remaining, len(mv.conflicts))),
+		g.SplitLayout(g.DirectionHorizontal, true, 250,
+			g.Layout{items},
+			details,
+		),
+	}
+	return
+}
@@ -0,0 +1,138 @@
+package editor
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/editor/mapview"
+	log "github.com/sirupsen/logrus"
+)
+
+// unsavedPrompt is a pending action that would throw away unsaved changes to the listed mapsets and archsets.
+type unsavedPrompt struct {
+	pending  bool // Whether the popup still needs to be opened.
+	mapsets  []*mapview.Mapset
+	archsets []*Archset
+	onDone   func() // Called once the changes are saved or discarded.
+}
+
+// confirmUnsaved calls onDone right away if none of the given mapsets or archsets have unsaved changes. Otherwise it asks whether to save or discard the changes first. If a prompt is already open, this one is queued until it is answered.
+func (e *Editor) confirmUnsaved(mapsets []*mapview.Mapset, archsets []*Archset, onDone func()) {
+	if e.unsaved.onDone != nil {
+		e.unsavedQueue = append(e.unsavedQueue, unsavedPrompt{
+			mapsets:  mapsets,
+			archsets: archsets,
+			onDone:   onDone,
+		})
+		return
+	}
+	p := unsavedPrompt{
+		onDone: onDone,
+	}
+	for _, m := range mapsets {
+		if m.Unsaved() {
+			p.mapsets = append(p.mapsets, m)
+		}
+	}
+	for _, a := range archsets {
+		if a.unsaved() {
+			p.archsets = append(p.archsets, a)
+		}
+	}
+	if len(p.mapsets) == 0 && len(p.archsets) == 0 {
+		onDone()
+		return
+	}
+	p.pending = true
+	e.unsaved = p
+}
+
+// finishUnsavedPrompt clears the answered prompt and moves on to the next queued one, if any.
+func (e *Editor) finishUnsavedPrompt() {
+	e.unsaved = unsavedPrompt{}
+	if len(e.unsavedQueue) == 0 {
+		return
+	}
+	q := e.unsavedQueue[0]
+	e.unsavedQueue = e.unsavedQueue[1:]
+	e.confirmUnsaved(q.mapsets, q.archsets, q.onDone)
+}
+
+// exit exits the editor once any unsaved changes are confirmed.
+func (e *Editor) exit() {
+	e.confirmUnsaved(e.mapsets, e.archsets, func() {
+		e.isRunning = false
+	})
+}
+
+// RequestExit asks the editor to exit from outside of the UI loop, such as from a signal handler. Unsaved mapsets are autosaved and the user is asked what to do with them.
+func (e *Editor) RequestExit() {
+	atomic.StoreInt32(&e.exitRequested, 1)
+	g.Update()
+}
+
+// handleExitRequest handles a pending RequestExit within the UI loop.
+func (e *Editor) handleExitRequest() {
+	if !atomic.CompareAndSwapInt32(&e.exitRequested, 1, 0) {
+		return
+	}
+	for _, m := range e.mapsets {
+		if err := m.Autosave(); err != nil {
+			log.Errorln(err)
+		}
+	}
+	e.exit()
+}
+
+func (e *Editor) drawUnsavedPrompt() {
+	p := &e.unsaved
+	if p.pending {
+		g.OpenPopup("Unsaved Changes")
+		p.pending = false
+	}
+	var items g.Layout
+	for _, m := range p.mapsets {
+		items = append(items, g.Label(fmt.Sprintf("Mapset: %s", m.Title())))
+	}
+	for _, a := range p.archsets {
+		items = append(items, g.Label(fmt.Sprintf("Archset: %s", a.filename)))
+	}
+	g.PopupModal("Unsaved Changes").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Label("The following have unsaved changes:"),
+		items,
+		g.Row(
+			g.Button("Save").OnClick(func() {
+				saved := true
+				for _, m := range p.mapsets {
+					if !m.Save() {
+						saved = false
+					}
+				}
+				for _, a := range p.archsets {
+					a.saveAll()
+				}
+				g.CloseCurrentPopup()
+				// Mapsets without a file show their own save dialog, so stop here and let the user try again.
+				if saved {
+					p.onDone()
+				}
+				e.finishUnsavedPrompt()
+			}),
+			g.Button("Discard").OnClick(func() {
+				for _, m := range p.mapsets {
+					if err := m.ClearRecovery(); err != nil {
+						log.Errorln(err)
+					}
+				}
+				g.CloseCurrentPopup()
+				p.onDone()
+				e.finishUnsavedPrompt()
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+				e.finishUnsavedPrompt()
+			}),
+		),
+	).Build()
+}
@@ -0,0 +1,446 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	g "github.com/AllenDang/giu"
+	imgui "github.com/AllenDang/imgui-go"
+	"github.com/chimera-rpg/go-editor/data"
+	"github.com/chimera-rpg/go-editor/editor/mapview"
+	"github.com/chimera-rpg/go-editor/widgets"
+	sdata "github.com/chimera-rpg/go-server/data"
+	log "github.com/sirupsen/logrus"
+)
+
+var worldBorderColor = color.RGBA{128, 128, 128, 255}
+var worldHoveredColor = color.RGBA{255, 255, 0, 255}
+var worldOverlapColor = color.RGBA{255, 0, 0, 128}
+var worldGapColor = color.RGBA{255, 192, 0, 96}
+
+// World is an atlas of every map under the MapsPath, laid out by the maps' world coordinates.
+type World struct {
+	isOpen       bool
+	isLoaded     bool
+	zoom         int32
+	allY         bool
+	focusedY     int32
+	gapDistance  int32
+	entries      []*worldEntry
+	colors       map[string]color.RGBA // Average colors of images, used for previews.
+	hovered      *worldEntry
+	dragging     *worldEntry
+	dragOrigin   image.Point
+	dragY, dragX int // Offset in tiles of the dragged map.
+	dragZ        int
+	verticalDrag bool // Dragging vertically changes Y rather than Z.
+}
+
+// worldEntry is a single map within the world atlas.
+type worldEntry struct {
+	filename  string
+	dataName  string
+	m         *sdata.Map // Copy loaded from disk, used when the map is not open in a mapset.
+	preview   *g.Texture
+	previewOf *sdata.Map // Map state the preview was built from.
+}
+
+// worldBox is the world-space extent of a map, with each range being [min, max).
+type worldBox struct {
+	entry  *worldEntry
+	y1, y2 int
+	x1, x2 int
+	z1, z2 int
+}
+
+func NewWorld() *World {
+	return &World{
+		zoom:        2,
+		allY:        true,
+		gapDistance: 4,
+		colors:      make(map[string]color.RGBA),
+	}
+}
+
+// reloadWorld loads every map under the MapsPath into the world atlas.
+func (e *Editor) reloadWorld() {
+	w := e.world
+	w.entries = nil
+	w.hovered = nil
+	w.dragging = nil
+	w.isLoaded = true
+
+	files, err := e.context.dataManager.GetMapFiles()
+	if err != nil {
+		log.Errorln(err)
+	}
+	for _, file := range files {
+		maps, err := e.context.dataManager.LoadMap(file)
+		if err != nil {
+			log.Errorln(err)
+			continue
+		}
+		for dataName, m := range maps {
+			w.entries = append(w.entries, &worldEntry{
+				filename: file,
+				dataName: dataName,
+				m:        m,
+			})
+		}
+	}
+	sort.Slice(w.entries, func(i, j int) bool {
+		if w.entries[i].filename == w.entries[j].filename {
+			return w.entries[i].dataName < w.entries[j].dataName
+		}
+		return w.entries[i].filename < w.entries[j].filename
+	})
+}
+
+// findEntry returns the entry of the map with the given data name.
+func (w *World) findEntry(dataName string) *worldEntry {
+	for _, entry := range w.entries {
+		if entry.dataName == dataName {
+			return entry
+		}
+	}
+	return nil
+}
+
+// findOpenMap returns the mapset and map for the given map file and data name if it is open.
+func (e *Editor) findOpenMap(filename, dataName string) (*mapview.Mapset, *data.UnReMap) {
+	for _, m := range e.mapsets {
+		if m.Filepath() == filename {
+			return m, m.Map(dataName)
+		}
+	}
+	return nil, nil
+}
+
+// getWorldMap returns the current state of the entry's map, preferring the open mapset's copy.
+func (e *Editor) getWorldMap(entry *worldEntry) *sdata.Map {
+	if _, v := e.findOpenMap(entry.filename, entry.dataName); v != nil {
+		return v.Get()
+	}
+	return entry.m
+}
+
+// openWorldEntry opens the entry's mapset, if needed, and switches to the entry's map.
+func (e *Editor) openWorldEntry(entry *worldEntry) *data.UnReMap {
+	m, _ := e.findOpenMap(entry.filename, entry.dataName)
+	if m == nil {
+		if err := e.openMap(entry.filename); err != nil {
+			log.Errorln(err)
+			return nil
+		}
+		m, _ = e.findOpenMap(entry.filename, entry.dataName)
+	}
+	if m == nil || !m.SelectMap(entry.dataName) {
+		log.Errorf("Map %s is missing from %s", entry.dataName, entry.filename)
+		return nil
+	}
+	return m.Map(entry.dataName)
+}
+
+// moveWorldEntry offsets the entry's world coordinates as a single undo step in its mapset.
+func (e *Editor) moveWorldEntry(entry *worldEntry, y, x, z int) {
+	v := e.openWorldEntry(entry)
+	if v == nil {
+		return
+	}
+	clone := v.Clone()
+	clone.Y += y
+	clone.X += x
+	clone.Z += z
+	v.Set(clone)
+}
+
+// getImageColor returns the average color of the opaque pixels of the given image.
+func (w *World) getImageColor(dm *data.Manager, name string) color.RGBA {
+	if c, ok := w.colors[name]; ok {
+		return c
+	}
+	var c color.RGBA
+	if img := dm.GetImage(name); img != nil {
+		var r, gr, b, count uint64
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				cr, cg, cb, ca := img.At(x, y).RGBA()
+				if ca == 0 {
+					continue
+				}
+				r += uint64(cr >> 8)
+				gr += uint64(cg >> 8)
+				b += uint64(cb >> 8)
+				count++
+			}
+		}
+		if count > 0 {
+			c = color.RGBA{uint8(r / count), uint8(gr / count), uint8(b / count), 255}
+		}
+	}
+	w.colors[name] = c
+	return c
+}
+
+// buildWorldPreview renders a low-detail top-down preview of the map, one pixel per tile, shaded by height.
+func (e *Editor) buildWorldPreview(entry *worldEntry, sm *sdata.Map) {
+	dm := e.context.dataManager
+	entry.previewOf = sm
+	if sm.Width <= 0 || sm.Depth <= 0 {
+		return
+	}
+	img := image.NewRGBA(image.Rect(0, 0, sm.Width, sm.Depth))
+	for x := 0; x < sm.Width; x++ {
+		for z := 0; z < sm.Depth; z++ {
+			for y := sm.Height - 1; y >= 0; y-- {
+				if y >= len(sm.Tiles) || x >= len(sm.Tiles[y]) || z >= len(sm.Tiles[y][x]) {
+					continue
+				}
+				tiles := sm.Tiles[y][x][z]
+				if len(tiles) == 0 {
+					continue
+				}
+				anim, face := dm.GetAnimAndFace(&tiles[len(tiles)-1], "", "")
+				imageName, err := dm.GetAnimFaceImage(anim, face)
+				if err != nil {
+					break
+				}
+				c := e.world.getImageColor(dm, imageName)
+				shade := 0.5 + 0.5*float64(y+1)/float64(sm.Height)
+				img.Set(x, z, color.RGBA{uint8(float64(c.R) * shade), uint8(float64(c.G) * shade), uint8(float64(c.B) * shade), c.A})
+				break
+			}
+		}
+	}
+	g.NewTextureFromRgba(img, func(t *g.Texture) {
+		if entry.previewOf == sm {
+			entry.preview = t
+		}
+	})
+}
+
+// getWorldBoxes returns the extents of every map shown at the world's current Y level, lowest first.
+func (e *Editor) getWorldBoxes() (boxes []worldBox) {
+	w := e.world
+	for _, entry := range w.entries {
+		sm := e.getWorldMap(entry)
+		b := worldBox{
+			entry: entry,
+			y1:    sm.Y,
+			y2:    sm.Y + sm.Height,
+			x1:    sm.X,
+			x2:    sm.X + sm.Width,
+			z1:    sm.Z,
+			z2:    sm.Z + sm.Depth,
+		}
+		if entry == w.dragging {
+			b.y1, b.y2 = b.y1+w.dragY, b.y2+w.dragY
+			b.x1, b.x2 = b.x1+w.dragX, b.x2+w.dragX
+			b.z1, b.z2 = b.z1+w.dragZ, b.z2+w.dragZ
+		}
+		if !w.allY && (int(w.focusedY) < b.y1 || int(w.focusedY) >= b.y2) {
+			continue
+		}
+		boxes = append(boxes, b)
+	}
+	sort.SliceStable(boxes, func(i, j int) bool {
+		return boxes[i].y1 < boxes[j].y1
+	})
+	return
+}
+
+// getWorldProblems returns the overlapping regions of maps and the gaps between neighboring maps that are no further apart than the gap distance.
+func (e *Editor) getWorldProblems(boxes []worldBox) (overlaps, gaps []image.Rectangle, overlapping map[*worldEntry]bool) {
+	overlapping = make(map[*worldEntry]bool)
+	gapDistance := int(e.world.gapDistance)
+	for i := 0; i < len(boxes); i++ {
+		for j := i + 1; j < len(boxes); j++ {
+			a, b := boxes[i], boxes[j]
+			if a.y1 >= b.y2 || b.y1 >= a.y2 {
+				continue
+			}
+			xOverlap := a.x1 < b.x2 && b.x1 < a.x2
+			zOverlap := a.z1 < b.z2 && b.z1 < a.z2
+			if xOverlap && zOverlap {
+				overlaps = append(overlaps, image.Rect(a.x1, a.z1, a.x2, a.z2).Intersect(image.Rect(b.x1, b.z1, b.x2, b.z2)))
+				overlapping[a.entry] = true
+				overlapping[b.entry] = true
+			} else if xOverlap {
+				if a.z1 > b.z1 {
+					a, b = b, a
+				}
+				if gap := b.z1 - a.z2; gap > 0 && gap <= gapDistance {
+					gaps = append(gaps, image.Rect(maxInt(a.x1, b.x1), a.z2, minInt(a.x2, b.x2), b.z1))
+				}
+			} else if zOverlap {
+				if a.x1 > b.x1 {
+					a, b = b, a
+				}
+				if gap := b.x1 - a.x2; gap > 0 && gap <= gapDistance {
+					gaps = append(gaps, image.Rect(a.x2, maxInt(a.z1, b.z1), b.x1, minInt(a.z2, b.z2)))
+				}
+			}
+		}
+	}
+	return
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (e *Editor) drawWorld() (title string, win *g.WindowWidget, layout g.Layout) {
+	w := e.world
+	title = "World"
+	win = g.Window(title)
+	win.IsOpen(&w.isOpen).Flags(g.WindowFlagsMenuBar).Pos(220, 30).Size(600, 500)
+
+	if !w.isLoaded {
+		e.reloadWorld()
+	}
+
+	boxes := e.getWorldBoxes()
+	overlaps, gaps, overlapping := e.getWorldProblems(boxes)
+
+	// Find the world-space bounds of the atlas.
+	var bounds image.Rectangle
+	for i, b := range boxes {
+		r := image.Rect(b.x1, b.z1, b.x2, b.z2)
+		if i == 0 {
+			bounds = r
+		} else {
+			bounds = bounds.Union(r)
+		}
+	}
+	bounds = bounds.Inset(-int(w.gapDistance))
+	zoom := int(w.zoom)
+
+	w.verticalDrag = false
+	widgets.KeyBinds(0,
+		widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(widgets.KeyShift), nil, func() {
+			w.verticalDrag = true
+		}),
+	).Build()
+
+	var canvasPos image.Point
+	lineHeight := imgui.CalcTextSize("World", false, 0)
+	layout = g.Layout{
+		g.MenuBar().Layout(
+			g.Menu("World").Layout(
+				g.MenuItem("Reload").OnClick(func() {
+					e.reloadWorld()
+				}),
+				g.Separator(),
+				g.Checkbox("All Y Levels", &w.allY),
+				g.SliderInt(&w.focusedY, -64, 64).Label("Y Level").Format("%d"),
+				g.SliderInt(&w.zoom, 1, 16).Label("Zoom").Format("%d"),
+				g.SliderInt(&w.gapDistance, 1, 16).Label("Gap Distance").Format("%d"),
+				g.Tooltip("Neighboring maps this many tiles apart or closer are flagged as having a gap."),
+			),
+		),
+		g.Custom(func() {
+			availW, availH := g.GetAvailableRegion()
+			g.Child().Border(false).Flags(g.WindowFlagsHorizontalScrollbar).Size(availW, availH-lineHeight.Y*3).Layout(
+				g.Custom(func() {
+					canvasPos = g.GetCursorScreenPos()
+					canvas := g.GetCanvas()
+					toScreen := func(x, z int) image.Point {
+						return canvasPos.Add(image.Pt((x-bounds.Min.X)*zoom, (z-bounds.Min.Y)*zoom))
+					}
+					for _, b := range boxes {
+						sm := e.getWorldMap(b.entry)
+						if b.entry.previewOf != sm {
+							e.buildWorldPreview(b.entry, sm)
+						}
+						p1, p2 := toScreen(b.x1, b.z1), toScreen(b.x2, b.z2)
+						if b.entry.preview != nil {
+							canvas.AddImageV(b.entry.preview, p1, p2, image.Pt(0, 0), image.Pt(1, 1), color.RGBA{255, 255, 255, 255})
+						}
+						col := worldBorderColor
+						if overlapping[b.entry] {
+							col = worldOverlapColor
+						}
+						if b.entry == w.hovered || b.entry == w.dragging {
+							col = worldHoveredColor
+						}
+						canvas.AddRect(p1, p2, col, 0, 0, 1)
+					}
+					for _, r := range overlaps {
+						canvas.AddRectFilled(toScreen(r.Min.X, r.Min.Y), toScreen(r.Max.X, r.Max.Y), worldOverlapColor, 0, 0)
+					}
+					for _, r := range gaps {
+						canvas.AddRectFilled(toScreen(r.Min.X, r.Min.Y), toScreen(r.Max.X, r.Max.Y), worldGapColor, 0, 0)
+					}
+					g.Dummy(float32(bounds.Dx()*zoom), float32(bounds.Dy()*zoom)).Build()
+				}),
+				g.Custom(func() {
+					if w.dragging != nil {
+						mousePos := g.GetMousePos()
+						dx := (mousePos.X - w.dragOrigin.X) / zoom
+						dz := (mousePos.Y - w.dragOrigin.Y) / zoom
+						if w.verticalDrag {
+							w.dragY, w.dragX, w.dragZ = -dz, 0, 0
+						} else {
+							w.dragY, w.dragX, w.dragZ = 0, dx, dz
+						}
+						if g.IsMouseReleased(g.MouseButtonLeft) {
+							if w.dragY != 0 || w.dragX != 0 || w.dragZ != 0 {
+								e.moveWorldEntry(w.dragging, w.dragY, w.dragX, w.dragZ)
+							}
+							w.dragging = nil
+							w.dragY, w.dragX, w.dragZ = 0, 0, 0
+						}
+						return
+					}
+					w.hovered = nil
+					if !g.IsItemHovered() {
+						return
+					}
+					mousePos := g.GetMousePos().Sub(canvasPos)
+					x := mousePos.X/zoom + bounds.Min.X
+					z := mousePos.Y/zoom + bounds.Min.Y
+					// Use the topmost map under the mouse.
+					for i := len(boxes) - 1; i >= 0; i-- {
+						if image.Pt(x, z).In(image.Rect(boxes[i].x1, boxes[i].z1, boxes[i].x2, boxes[i].z2)) {
+							w.hovered = boxes[i].entry
+							break
+						}
+					}
+					if w.hovered == nil {
+						return
+					}
+					if g.IsMouseDoubleClicked(g.MouseButtonLeft) {
+						e.openWorldEntry(w.hovered)
+					} else if g.IsMouseClicked(g.MouseButtonLeft) {
+						w.dragging = w.hovered
+						w.dragOrigin = g.GetMousePos()
+					}
+				}),
+			).Build()
+		}),
+		g.Custom(func() {
+			if w.hovered != nil {
+				sm := e.getWorldMap(w.hovered)
+				relPath, _ := e.context.dataManager.GetRelativeMapPath(w.hovered.filename)
+				g.Label(fmt.Sprintf("%s: %s(%s) at %dx%dx%d, %dx%dx%d", relPath, w.hovered.dataName, sm.Name, sm.X, sm.Z, sm.Y, sm.Width, sm.Depth, sm.Height)).Build()
+			} else {
+				g.Label(fmt.Sprintf("%d maps, %d overlaps, %d gaps", len(boxes), len(overlaps), len(gaps))).Build()
+			}
+			g.Label("Drag to move a map, shift-drag to change its Y, double-click to open it.").Build()
+		}),
+	}
+	return
+}
@@ -15,7 +15,7 @@ var Textures map[string]*data.ImageTexture
 
 func Load() {
 	Textures = make(map[string]*data.ImageTexture)
-	files := []string{"dropper", "dropper-focus", "eraser", "eraser-focus", "fill", "fill-focus", "insert", "insert-focus", "select", "select-focus", "cselect", "cselect-focus", "lselect", "lselect-focus", "wand", "wand-focus", "loading", "missing", "tl", "tr", "bl", "br", "l", "t", "r", "b", "u", "d", "delete", "blank"}
+	files := []string{"dropper", "dropper-focus", "eraser", "eraser-focus", "fill", "fill-focus", "insert", "insert-focus", "select", "select-focus", "cselect", "cselect-focus", "lselect", "lselect-focus", "wand", "wand-focus", "path", "path-focus", "scatter", "scatter-focus", "move", "move-focus", "loading", "missing", "tl", "tr", "bl", "br", "l", "t", "r", "b", "u", "d", "delete", "blank"}
 	for _, name := range files {
 		go func(name string) {
 			filedata, _ := f.Open(name + ".png")
@@ -32,14 +32,18 @@ type Mapset struct {
 	newY, newX, newZ                             int32
 	newDataName, newName                         string
 	loreEditor, descEditor, scriptEditor         imgui.TextEditor
-	zoom                                         int32
-	showGrid                                     bool
-	showYGrids                                   bool
-	onionskinY, onionskinX, onionskinZ           bool
-	onionSkinGtIntensity, onionSkinLtIntensity   int32
+	viewports                                    []*viewport
+	activeViewportIndex                          int
+	lights                                       lightMap
+	walk                                         walkMap
+	path                                         pathTest
+	moverHeight                                  int32 // Height of the mover used for walkability and path tests.
+	moverWidth                                   int32 // Width of the mover used for walkability and path tests.
+	moverDepth                                   int32 // Depth of the mover used for walkability and path tests.
 	keepSameTile                                 bool
 	uniqueTileVisits                             bool
 	ShouldClose                                  bool
+	CloseRequested                               bool           // Set when the user asks to close the mapset, before any unsaved changes are confirmed.
 	visitedCoords                                SelectedCoords // Coordinates visited during mouse drag.
 	mouseHeld                                    map[g.MouseButton]bool
 	toolBinds                                    map[g.MouseButton]int
@@ -49,6 +53,24 @@ type Mapset struct {
 	saveMapCWD, saveMapFilename, pendingFilename string
 	isWheelSelecting                             bool
 	pendingClone                                 *sdata.Map
+	pendingTab                                   *data.UnReMap // Map tab to switch to on the next draw.
+	pendingFocus                                 *Coords       // Tile to move the cursor to once the pending tab is switched to.
+	restore                                      backupRestore
+	recovery                                     recovery
+	autosaved                                    map[*data.UnReMap]int // Revisions of the maps when last autosaved.
+	untitledRecovery                             string                // Name the mapset is autosaved under while it has no file.
+	diff                                         diffOverlay
+	tiled                                        tiledTransfer
+	generator                                    generator
+	scatter                                      scatterBrush
+	terrain                                      terrain
+	symmetry                                     symmetry
+	moveStart                                    Coords // Where the move tool's drag started.
+	mapOp                                        mapOperation
+	instances                                    instanceSelection
+	selectBy                                     selectBy
+	flood                                        floodOptions
+	bucketFill                                   bool // Whether the fill tool fills the clicked region rather than the selection.
 	//
 	selectionWidget SelectionWidget
 }
@@ -56,28 +78,26 @@ type Mapset struct {
 func NewMapset(context Context, name string, maps map[string]*sdata.Map) *Mapset {
 	shortname, _ := context.DataManager().GetRelativeMapPath(name)
 	m := &Mapset{
-		filename:             name,
-		shortname:            shortname,
-		zoom:                 3.0,
-		showGrid:             false,
-		showYGrids:           false,
-		onionskinY:           true,
-		onionskinX:           false,
-		onionskinZ:           false,
-		onionSkinGtIntensity: 240,
-		onionSkinLtIntensity: 10,
-		keepSameTile:         true,
-		uniqueTileVisits:     true,
-		newW:                 1,
-		newH:                 1,
-		newD:                 1,
-		context:              context,
-		loreEditor:           imgui.NewTextEditor(),
-		descEditor:           imgui.NewTextEditor(),
-		scriptEditor:         imgui.NewTextEditor(),
-		mouseHeld:            make(map[g.MouseButton]bool),
-		toolBinds:            make(map[g.MouseButton]int),
-		saveMapCWD:           context.DataManager().MapsPath,
+		filename:         name,
+		shortname:        shortname,
+		viewports:        []*viewport{newViewport()},
+		moverHeight:      1,
+		moverWidth:       1,
+		moverDepth:       1,
+		keepSameTile:     true,
+		uniqueTileVisits: true,
+		newW:             1,
+		newH:             1,
+		newD:             1,
+		context:          context,
+		loreEditor:       imgui.NewTextEditor(),
+		descEditor:       imgui.NewTextEditor(),
+		scriptEditor:     imgui.NewTextEditor(),
+		mouseHeld:        make(map[g.MouseButton]bool),
+		toolBinds:        make(map[g.MouseButton]int),
+		saveMapCWD:       context.DataManager().MapsPath,
+		scatter:          newScatterBrush(),
+		terrain:          newTerrain(),
 	}
 	m.loreEditor.SetShowWhitespaces(false)
 	m.descEditor.SetShowWhitespaces(false)
@@ -99,30 +119,15 @@ func NewMapset(context Context, name string, maps map[string]*sdata.Map) *Mapset
 	return m
 }
 
-func (m *Mapset) getMapPointFromMouse(p image.Point) (h image.Point, err error) {
-	dm := m.context.DataManager()
+func (m *Mapset) getMapPointFromMouse(vp *viewport, p image.Point) (y, x, z int, err error) {
 	sm := m.CurrentMap()
 
-	scale := float64(m.zoom)
-	padding := 4
-	tWidth := int(dm.AnimationsConfig.TileWidth)
-	tHeight := int(dm.AnimationsConfig.TileHeight)
+	scale := float64(vp.zoom)
 
 	hitX := int(float64(p.X) / scale)
 	hitY := int(float64(p.Y) / scale)
 
-	xOffset := m.focusedY*int(-dm.AnimationsConfig.YStep.X) + padding
-	yOffset := m.focusedY*int(dm.AnimationsConfig.YStep.Y) + padding + (sm.Get().Height * int(-dm.AnimationsConfig.YStep.Y))
-
-	nearestX := (hitX+xOffset)/tWidth - 1
-	nearestY := (hitY - yOffset) / tHeight
-	if nearestX >= 0 && nearestX < sm.Get().Width && nearestY >= 0 && nearestY < sm.Get().Depth {
-		h.X = nearestX
-		h.Y = nearestY
-	} else {
-		err = errors.New("Point OOB")
-	}
-	return
+	return m.getTileFromView(vp, sm.Get(), hitX, hitY)
 }
 
 func (m *Mapset) Filepath() string {
@@ -130,21 +135,19 @@ func (m *Mapset) Filepath() string {
 }
 
 func (m *Mapset) saveAll() {
-	maps := make(map[string]*sdata.Map)
-	for _, v := range m.maps {
-		if v.Unsaved() {
-			v.Save()
-		}
-		maps[v.DataName()] = v.SavedMap()
-	}
 	targetFilename := m.filename
 	if targetFilename == "" {
 		targetFilename = m.pendingFilename
 	}
 	if targetFilename == "" {
+		// The maps stay unsaved until a file is chosen and written.
 		m.showSave = true
 		return
 	}
+	maps := make(map[string]*sdata.Map)
+	for _, v := range m.maps {
+		maps[v.DataName()] = v.Get()
+	}
 	err := m.context.DataManager().SaveMap(targetFilename, maps)
 	if err != nil {
 		m.unsaved = true
@@ -152,13 +155,36 @@ func (m *Mapset) saveAll() {
 		// TODO: Report error to the user.
 		return
 	}
+	for _, v := range m.maps {
+		if v.Unsaved() {
+			v.Save()
+		}
+	}
 	m.filename = targetFilename
 	m.unsaved = false
+	if err := m.ClearRecovery(); err != nil {
+		log.Println(err)
+	}
 	// TODO: Some sort of UI notification.
 }
 
+// close asks for the mapset to be closed. The editor confirms first if there are unsaved changes.
 func (m *Mapset) close() {
-	m.ShouldClose = true
+	m.CloseRequested = true
+}
+
+// Save saves every map in the mapset and returns whether the mapset no longer has unsaved changes.
+func (m *Mapset) Save() bool {
+	m.saveAll()
+	return !m.Unsaved()
+}
+
+// Title returns the name of the mapset as shown in its window.
+func (m *Mapset) Title() string {
+	if m.filename == "" {
+		return "New Mapset"
+	}
+	return m.shortname
 }
 
 func (m *Mapset) resizeMap(u, d, l, r, t, b int) {
@@ -212,6 +238,7 @@ func (m *Mapset) resizeMap(u, d, l, r, t, b int) {
 		}
 	}
 	cm.Set(newMap)
+	m.selectArchetype()
 }
 
 func (m *Mapset) insertArchetype(t *sdata.Map, arch string, y, x, z, pos int) error {
@@ -312,6 +339,43 @@ func (m *Mapset) CurrentMap() *data.UnReMap {
 	return m.maps[m.currentMapIndex]
 }
 
+// Map returns the map with the given data name, or nil if the mapset has no such map.
+func (m *Mapset) Map(dataName string) *data.UnReMap {
+	for _, v := range m.maps {
+		if v.DataName() == dataName {
+			return v
+		}
+	}
+	return nil
+}
+
+// SelectMap switches to the tab of the map with the given data name.
+func (m *Mapset) SelectMap(dataName string) bool {
+	if v := m.Map(dataName); v != nil {
+		m.pendingTab = v
+		return true
+	}
+	return false
+}
+
+// SetMap replaces the map with the given data name as an undoable change, adding it if the mapset has no such map.
+func (m *Mapset) SetMap(dataName string, sm *sdata.Map) {
+	m.replaceMaps(map[string]*sdata.Map{dataName: sm})
+}
+
+// RemoveMap removes the map with the given data name from the mapset.
+func (m *Mapset) RemoveMap(dataName string) bool {
+	for i, v := range m.maps {
+		if v.DataName() == dataName {
+			m.deleteMap(i)
+			m.unsaved = true
+			m.ensure()
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Mapset) Unsaved() bool {
 	if m.unsaved {
 		return true
@@ -360,24 +424,60 @@ func (m *Mapset) ensure() {
 	if m.hoveredX >= w {
 		m.hoveredX = w - 1
 	}
+	for _, vp := range m.viewports {
+		if vp.focusedY >= h {
+			vp.focusedY = h - 1
+		}
+	}
 	m.selectedCoords.Clear()
 	m.selectingCoords.Clear()
+	m.selectArchetype()
+}
+
+// undo undoes the last change to the current map and points the archetype editor at the restored state.
+func (m *Mapset) undo() {
+	if cm := m.CurrentMap(); cm != nil {
+		cm.Undo()
+		m.selectArchetype()
+	}
+}
+
+// redo redoes the last undone change to the current map and points the archetype editor at the restored state.
+func (m *Mapset) redo() {
+	if cm := m.CurrentMap(); cm != nil {
+		cm.Redo()
+		m.selectArchetype()
+	}
 }
 
 func (m *Mapset) moveCursor(y, x, z, i int) {
 	m.focusedY = y
+	m.activeViewport().focusedY = y
 	m.focusedX = x
 	m.focusedZ = z
 	m.focusedI = i
 	m.selectArchetype()
 }
 
+// FocusTile moves the cursor to the given tile. If a map tab switch is pending, the cursor is moved once the switch happens.
+func (m *Mapset) FocusTile(y, x, z int) {
+	if m.pendingTab != nil && m.pendingTab != m.CurrentMap() {
+		m.pendingFocus = &Coords{y, x, z}
+		return
+	}
+	m.moveCursor(y, x, z, 0)
+}
+
 func (m *Mapset) selectArchetype() {
 	sm := m.CurrentMap()
 	if sm == nil {
 		m.context.ArchEditor().SetArchetype(nil)
 		return
 	}
+	if m.instances.active && !m.selectedCoords.Empty() {
+		m.context.ArchEditor().SetArchetypes(m.collectInstances())
+		return
+	}
 	archs := sm.GetArchs(m.focusedY, m.focusedX, m.focusedZ)
 	if m.focusedI >= 0 && m.focusedI < len(archs) {
 		m.context.ArchEditor().SetArchetype(&archs[m.focusedI])
@@ -15,56 +15,94 @@ import (
 )
 
 type archDrawable struct {
-	z     int
-	x, y  int
-	cY    int
-	w, h  int
-	large bool
-	t     *data.ImageTexture
-	c     color.RGBA
+	z          int
+	x, y       int
+	cY         int
+	w, h       int
+	tY, tX, tZ int // Tile coordinates, used for onionskinning.
+	large      bool
+	t          *data.ImageTexture
+	c          color.RGBA
 }
 
-func (m *Mapset) getMapSize(v *data.UnReMap) (float32, float32) {
-	sm := v.Get()
-	dm := m.context.DataManager()
-	scale := int(m.zoom)
-	tWidth := int(dm.AnimationsConfig.TileWidth)
-	tHeight := int(dm.AnimationsConfig.TileHeight)
-	yStep := dm.AnimationsConfig.YStep
-	padding := 4
-	cWidth := sm.Width * tWidth
-	cHeight := sm.Depth * tHeight
+// bounds returns the canvas-relative rectangle that the drawable covers.
+func (d *archDrawable) bounds(tWidth, tHeight, scale int) image.Rectangle {
+	r := image.Rect(d.x, d.y, d.w, d.h)
+	if d.large {
+		r = r.Union(image.Rect(d.x, d.cY, d.x+tWidth*scale, d.cY+tHeight*scale))
+	}
+	return r
+}
+
+// mapDrawCache holds the sorted archetype drawables of a map so they only need to be rebuilt when the map or view changes.
+type mapDrawCache struct {
+	v         *data.UnReMap
+	revision  int
+	zoom      int32
+	viewMode  int
+	viewSlice int
+	valid     bool
+	drawables []archDrawable
+	failed    int // Archetypes that had no image when the cache was built.
+}
+
+// stale returns if the cache no longer matches the given map state.
+func (c *mapDrawCache) stale(v *data.UnReMap, zoom int32, viewMode, viewSlice int) bool {
+	return !c.valid || c.v != v || c.revision != v.Revision() || c.zoom != zoom || c.viewMode != viewMode || c.viewSlice != viewSlice
+}
+
+// Invalidate forces the cache to be rebuilt on the next draw.
+func (c *mapDrawCache) Invalidate() {
+	c.valid = false
+	c.drawables = nil
+}
+
+// InvalidateDrawCache forces the map's drawables, light levels, and walkability to be rebuilt, such as when archetypes or animations are reloaded.
+func (m *Mapset) InvalidateDrawCache() {
+	for _, vp := range m.viewports {
+		vp.drawCache.Invalidate()
+	}
+	m.lights.Invalidate()
+	m.walk.Invalidate()
+}
 
-	canvasWidth := int((cWidth + (sm.Height * int(yStep.X)) + padding*2) * scale)
-	canvasHeight := int((cHeight + (sm.Height * int(-yStep.Y)) + padding*2) * scale)
-	return float32(canvasWidth), float32(canvasHeight)
+func (m *Mapset) getMapSize(vp *viewport, v *data.UnReMap) (float32, float32) {
+	scale := int(vp.zoom)
+	w, h := m.getViewSize(vp, v.Get())
+	return float32(w * scale), float32(h * scale)
 }
 
-func (m *Mapset) drawMap(v *data.UnReMap) {
+func (m *Mapset) drawMap(vp *viewport, v *data.UnReMap) {
 	sm := v.Get()
 	dm := m.context.DataManager()
 
 	canvas := g.GetCanvas()
 	pos := g.GetCursorScreenPos()
-	scale := int(m.zoom)
+	scale := int(vp.zoom)
 	tWidth := int(dm.AnimationsConfig.TileWidth)
 	tHeight := int(dm.AnimationsConfig.TileHeight)
 	yStep := dm.AnimationsConfig.YStep
 	padding := 4
-	cWidth := sm.Width * tWidth
-	cHeight := sm.Depth * tHeight
+	oblique := vp.viewMode == viewOblique
 
-	canvasWidth := int((cWidth + (sm.Height * int(yStep.X)) + padding*2) * scale)
-	canvasHeight := int((cHeight + (sm.Height * int(-yStep.Y)) + padding*2) * scale)
+	canvasWidth, canvasHeight := m.getViewSize(vp, sm)
+	canvasWidth *= scale
+	canvasHeight *= scale
 
 	startX := padding
 	startY := padding + (sm.Height * int(-yStep.Y))
 
+	// Returns the scaled screen position of a tile's origin.
+	getTilePos := func(y, x, z int) (int, int) {
+		oX, oY := m.projectTile(vp, sm, y, x, z)
+		return pos.X + oX*scale, pos.Y + oY*scale
+	}
+
 	drawRect := func(y, x, z int, col color.RGBA) {
-		xOffset := y * int(yStep.X)
-		yOffset := y * int(-yStep.Y)
-		oX := pos.X + (x*tWidth+xOffset+startX)*scale
-		oY := pos.Y + (z*tHeight-yOffset+startY)*scale
+		if !m.isTileInView(vp, y, x, z) {
+			return
+		}
+		oX, oY := getTilePos(y, x, z)
 		oW := (tWidth) * scale
 		oH := (tHeight) * scale
 
@@ -72,22 +110,24 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 	}
 
 	drawBox := func(y, x, z int, col color.RGBA) {
-		xOffset := y * int(yStep.X)
-		yOffset := y * int(-yStep.Y)
+		if !m.isTileInView(vp, y, x, z) {
+			return
+		}
+		oW := (tWidth) * scale
+		oH := (tHeight) * scale
+
+		// Flat views have no depth to show, so just outline the tile.
+		if !oblique {
+			oX, oY := getTilePos(y, x, z)
+			canvas.AddRect(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), col, 0, 0, 1)
+			return
+		}
 
 		// Calc bottom
-		o1X := pos.X + (x*tWidth+xOffset+startX)*scale
-		o1Y := pos.Y + (z*tHeight-yOffset+startY)*scale
+		o1X, o1Y := getTilePos(y, x, z)
 
 		// Calc top
-		y += 1
-		xOffset = y * int(yStep.X)
-		yOffset = y * int(-yStep.Y)
-		o2X := pos.X + (x*tWidth+xOffset+startX)*scale
-		o2Y := pos.Y + (z*tHeight-yOffset+startY)*scale
-
-		oW := (tWidth) * scale
-		oH := (tHeight) * scale
+		o2X, o2Y := getTilePos(y+1, x, z)
 
 		// Left
 		canvas.AddQuad(image.Pt(o1X, o1Y), image.Pt(o2X, o2Y+2), image.Pt(o2X, o2Y+oH-1), image.Pt(o1X, o1Y+oH-3), col, 1)
@@ -98,6 +138,10 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 	}
 
 	drawHeightBox := func(y, x, z int, col color.RGBA) {
+		// Height boxes only make sense in the oblique projection.
+		if !oblique {
+			return
+		}
 		// Get position of closest arch below the target coordinates.
 		yPos := y
 		for ; yPos >= 0; yPos-- {
@@ -114,19 +158,11 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 			}
 		}
 
-		xOffset := y * int(yStep.X)
-		yOffset := y * int(-yStep.Y)
-
 		// Calc bottom
-		o1X := pos.X + (x*tWidth+xOffset+startX)*scale
-		o1Y := pos.Y + (z*tHeight-yOffset+startY)*scale
+		o1X, o1Y := getTilePos(y, x, z)
 
 		// Calc top
-		y = yPos
-		xOffset = y * int(yStep.X)
-		yOffset = y * int(-yStep.Y)
-		o2X := pos.X + (x*tWidth+xOffset+startX)*scale
-		o2Y := pos.Y + (z*tHeight-yOffset+startY)*scale
+		o2X, o2Y := getTilePos(yPos, x, z)
 
 		oW := (tWidth) * scale
 		oH := (tHeight) * scale
@@ -141,13 +177,11 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 	canvas.AddRectFilled(pos, pos.Add(image.Pt(canvasWidth, canvasHeight)), col, 0, 0)
 
 	col = color.RGBA{255, 255, 255, 255}
-	var drawables []archDrawable
 	//
 	getArchDrawable := func(y, x, z, t int, arch *sdata.Archetype) (archDrawable, error) {
-		xOffset := y * int(yStep.X)
-		yOffset := y * int(-yStep.Y)
-		oX := pos.X + (x*tWidth+xOffset+startX)*scale
-		oY := pos.Y + (z*tHeight-yOffset+startY)*scale
+		oX, oY := m.projectTile(vp, sm, y, x, z)
+		oX *= scale
+		oY *= scale
 		oH, oW, oD := dm.GetArchDimensions(arch)
 		large := false
 		if oH > 1 || oW > 1 || oD > 1 {
@@ -161,10 +195,20 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 		}
 
 		// calc render z
-		indexZ := z
-		indexX := x
-		indexY := y
-		zIndex := (indexZ * sm.Height * sm.Width) + (sm.Depth * indexY) - (indexX) + t
+		var zIndex int
+		switch vp.viewMode {
+		case viewCrossXY:
+			zIndex = (y*sm.Width+x)*1000 + t
+		case viewCrossZY:
+			zIndex = (y*sm.Depth+z)*1000 + t
+		case viewTopDown:
+			zIndex = (z*sm.Width+x)*1000 + t
+		default:
+			indexZ := z
+			indexX := x
+			indexY := y
+			zIndex = (indexZ * sm.Height * sm.Width) + (sm.Depth * indexY) - (indexX) + t
+		}
 
 		var tex *data.ImageTexture
 		var ok bool
@@ -195,6 +239,9 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 				cY:    cY,
 				w:     oX + int(tex.Width)*scale,
 				h:     oY + int(tex.Height)*scale,
+				tY:    y,
+				tX:    x,
+				tZ:    z,
 				c:     color.RGBA{0, 0, 0, 255},
 				t:     tex,
 				large: large,
@@ -202,52 +249,51 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 		}
 		return archDrawable{}, fmt.Errorf("couldn't create archDrawable")
 	}
-	// Draw archetypes.
-	var alphaY, alphaX, alphaZ int32
-	// TODO: Adjust onion skins based upon distance from cursor.
-	for y := 0; y < sm.Height; y++ {
-		alphaY = 255
-		if m.onionskinY {
-			if y < m.focusedY {
-				alphaY = m.onionSkinGtIntensity
-			} else if y > m.focusedY {
-				alphaY = m.onionSkinLtIntensity
-			}
-		}
-		for x := sm.Width - 1; x >= 0; x-- {
-			alphaX = 255
-			if m.onionskinX {
-				if x < m.focusedX {
-					alphaX = m.onionSkinGtIntensity
-				} else if x > m.focusedX {
-					alphaX = m.onionSkinLtIntensity
-				}
-			}
-			for z := 0; z < sm.Depth; z++ {
-				alphaZ = 255
-				if m.onionskinZ {
-					if z > m.focusedZ {
-						alphaZ = m.onionSkinGtIntensity
-					} else if z < m.focusedZ {
-						alphaZ = m.onionSkinLtIntensity
+
+	// Rebuild our drawables if the map or view has changed since they were last cached.
+	if vp.drawCache.stale(v, vp.zoom, vp.viewMode, m.viewSlice(vp)) {
+		var drawables []archDrawable
+		var failed int
+		for y := 0; y < sm.Height; y++ {
+			for x := sm.Width - 1; x >= 0; x-- {
+				for z := 0; z < sm.Depth; z++ {
+					if !m.isTileInView(vp, y, x, z) {
+						continue
 					}
-				}
-				col.A = uint8(math.Min(math.Min(float64(alphaX), float64(alphaY)), float64(alphaZ)))
-				for t := 0; t < len(sm.Tiles[y][x][z]); t++ {
-					drawable, err := getArchDrawable(y, x, z, t, &sm.Tiles[y][x][z][t])
-					if err != nil {
-						log.Println(err)
-					} else {
-						drawable.c = col
-						drawables = append(drawables, drawable)
+					for t := 0; t < len(sm.Tiles[y][x][z]); t++ {
+						drawable, err := getArchDrawable(y, x, z, t, &sm.Tiles[y][x][z][t])
+						if err != nil {
+							failed++
+						} else {
+							drawables = append(drawables, drawable)
+						}
 					}
 				}
 			}
 		}
+		// Failed archetypes stay out of the cache until the map, view, or images change, so only log them once per rebuild.
+		if failed > 0 {
+			log.Printf("couldn't create %d archDrawables\n", failed)
+		}
+		// Sort our drawables.
+		sort.Slice(drawables, func(i, j int) bool {
+			return drawables[i].z < drawables[j].z
+		})
+		vp.drawCache = mapDrawCache{
+			v:         v,
+			revision:  v.Revision(),
+			zoom:      vp.zoom,
+			viewMode:  vp.viewMode,
+			viewSlice: m.viewSlice(vp),
+			valid:     true,
+			drawables: drawables,
+			failed:    failed,
+		}
 	}
 
 	// Show our archetype to insert if possible.
-	if m.isToolBound(insertTool) {
+	var preview *archDrawable
+	if m.isToolBound(insertTool) && m.isTileInView(vp, m.hoveredY, m.hoveredX, m.hoveredZ) {
 		arch := m.context.DataManager().GetArchetype(m.context.SelectedArch())
 		if arch != nil {
 			drawable, err := getArchDrawable(m.hoveredY, m.hoveredX, m.hoveredZ, 999, arch)
@@ -257,20 +303,102 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 				drawable.c = color.RGBA{
 					255, 255, 255, 128,
 				}
-				drawables = append(drawables, drawable)
+				preview = &drawable
+			}
+		}
+	}
+
+	// Only draw what is within the visible scroll region. An empty view means we have no region information yet, so draw everything.
+	view := vp.viewRect
+	isVisible := func(r image.Rectangle) bool {
+		return view.Empty() || r.Overlaps(view)
+	}
+	visibleTiles := func(y int) (x1, x2, z1, z2 int) {
+		x1, x2, z1, z2 = 0, sm.Width-1, 0, sm.Depth-1
+		switch vp.viewMode {
+		case viewCrossXY:
+			z1, z2 = m.focusedZ, m.focusedZ
+			return
+		case viewCrossZY:
+			x1, x2 = m.focusedX, m.focusedX
+			return
+		case viewTopDown:
+			if y != vp.focusedY {
+				x2, z2 = -1, -1
+			}
+			return
+		}
+		if view.Empty() {
+			return
+		}
+		xOffset := y * int(yStep.X)
+		yOffset := y * int(-yStep.Y)
+		x1 = int(math.Max(float64(x1), float64((view.Min.X/scale-xOffset-startX)/tWidth-1)))
+		x2 = int(math.Min(float64(x2), float64((view.Max.X/scale-xOffset-startX)/tWidth+1)))
+		z1 = int(math.Max(float64(z1), float64((view.Min.Y/scale+yOffset-startY)/tHeight-1)))
+		z2 = int(math.Min(float64(z2), float64((view.Max.Y/scale+yOffset-startY)/tHeight+1)))
+		return
+	}
+
+	// Get the onionskin alpha for a given tile.
+	var alphaY, alphaX, alphaZ int32
+	// TODO: Adjust onion skins based upon distance from cursor.
+	getAlpha := func(y, x, z int) uint8 {
+		alphaY = 255
+		if vp.onionskinY {
+			if y < vp.focusedY {
+				alphaY = vp.onionSkinGtIntensity
+			} else if y > vp.focusedY {
+				alphaY = vp.onionSkinLtIntensity
 			}
 		}
+		alphaX = 255
+		if vp.onionskinX {
+			if x < m.focusedX {
+				alphaX = vp.onionSkinGtIntensity
+			} else if x > m.focusedX {
+				alphaX = vp.onionSkinLtIntensity
+			}
+		}
+		alphaZ = 255
+		if vp.onionskinZ {
+			if z > m.focusedZ {
+				alphaZ = vp.onionSkinGtIntensity
+			} else if z < m.focusedZ {
+				alphaZ = vp.onionSkinLtIntensity
+			}
+		}
+		return uint8(math.Min(math.Min(float64(alphaX), float64(alphaY)), float64(alphaZ)))
+	}
+
+	drawDrawable := func(d *archDrawable) {
+		canvas.AddImageV(d.t.Texture, pos.Add(image.Pt(d.x, d.y)), pos.Add(image.Pt(d.w, d.h)), image.Pt(0, 0), image.Pt(1, 1), d.c)
 	}
 
-	// Sort our drawables.
-	sort.Slice(drawables, func(i, j int) bool {
-		return drawables[i].z < drawables[j].z
-	})
 	// Render them.
-	for _, d := range drawables {
-		canvas.AddImageV(d.t.Texture, image.Pt(d.x, d.y), image.Pt(d.w, d.h), image.Pt(0, 0), image.Pt(1, 1), d.c)
+	var visible []archDrawable
+	for _, d := range vp.drawCache.drawables {
+		if !isVisible(d.bounds(tWidth, tHeight, scale)) {
+			continue
+		}
+		if preview != nil && preview.z < d.z {
+			drawDrawable(preview)
+			visible = append(visible, *preview)
+			preview = nil
+		}
+		col.A = 255
+		if oblique {
+			col.A = getAlpha(d.tY, d.tX, d.tZ)
+		}
+		d.c = col
+		drawDrawable(&d)
+		visible = append(visible, d)
 	}
-	for _, d := range drawables {
+	if preview != nil {
+		drawDrawable(preview)
+		visible = append(visible, *preview)
+	}
+	for _, d := range visible {
 		if d.large {
 			col := color.RGBA{
 				R: 0,
@@ -278,29 +406,118 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 				B: 255,
 				A: d.c.A,
 			}
-			canvas.AddRect(image.Pt(d.x, d.cY), image.Pt(d.x+tWidth*scale, d.cY+tHeight*scale), col, 0, 0, 0.5)
+			canvas.AddRect(pos.Add(image.Pt(d.x, d.cY)), pos.Add(image.Pt(d.x+tWidth*scale, d.cY+tHeight*scale)), col, 0, 0, 0.5)
+		}
+	}
+
+	// Draw lighting preview.
+	if vp.showLighting {
+		lights := m.getLightMap(v)
+		oW := (tWidth) * scale
+		oH := (tHeight) * scale
+		for y := 0; y < sm.Height; y++ {
+			// The oblique projection overlaps Y levels, so only shade the focused one.
+			if oblique && y != vp.focusedY {
+				continue
+			}
+			x1, x2, z1, z2 := visibleTiles(y)
+			for x := x1; x <= x2; x++ {
+				for z := z1; z <= z2; z++ {
+					oX, oY := getTilePos(y, x, z)
+					if !isVisible(image.Rect(oX-pos.X, oY-pos.Y, oX-pos.X+oW, oY-pos.Y+oH)) {
+						continue
+					}
+					level := lights.Get(y, x, z)
+					canvas.AddRectFilled(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), color.RGBA{0, 0, 0, uint8((1 - level) * 200)}, 0, 0)
+					if level <= pitchBlackLevel {
+						canvas.AddLine(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), pitchBlackColor, 1)
+						canvas.AddLine(image.Pt(oX+oW, oY), image.Pt(oX, oY+oH), pitchBlackColor, 1)
+					}
+				}
+			}
+		}
+	}
+
+	// Draw walkability.
+	if vp.showWalkability {
+		walk := m.getWalkMap(v)
+		oW := (tWidth) * scale
+		oH := (tHeight) * scale
+		for y := 0; y < sm.Height; y++ {
+			if oblique && y != vp.focusedY {
+				continue
+			}
+			x1, x2, z1, z2 := visibleTiles(y)
+			for x := x1; x <= x2; x++ {
+				for z := z1; z <= z2; z++ {
+					var c color.RGBA
+					switch walk.Get(y, x, z) {
+					case tileBlocking:
+						c = blockingColor
+					case tileWalkable:
+						c = walkableColor
+					case tileClimbable:
+						c = climbableColor
+					default:
+						continue
+					}
+					oX, oY := getTilePos(y, x, z)
+					if !isVisible(image.Rect(oX-pos.X, oY-pos.Y, oX-pos.X+oW, oY-pos.Y+oH)) {
+						continue
+					}
+					canvas.AddRectFilled(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), c, 0, 0)
+				}
+			}
+		}
+	}
+
+	// Draw diff.
+	if tiles := m.diff[v.DataName()]; len(tiles) > 0 {
+		oW := (tWidth) * scale
+		oH := (tHeight) * scale
+		for coord, kind := range tiles {
+			y, x, z := coord[0], coord[1], coord[2]
+			// The oblique projection overlaps Y levels, so only mark the focused one. The other views only show their slice.
+			if (oblique && y != vp.focusedY) || !m.isTileInView(vp, y, x, z) {
+				continue
+			}
+			var c color.RGBA
+			switch kind {
+			case data.TileAdded:
+				c = diffAddedColor
+			case data.TileRemoved:
+				c = diffRemovedColor
+			default:
+				c = diffChangedColor
+			}
+			oX, oY := getTilePos(y, x, z)
+			if !isVisible(image.Rect(oX-pos.X, oY-pos.Y, oX-pos.X+oW, oY-pos.Y+oH)) {
+				continue
+			}
+			canvas.AddRectFilled(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), c, 0, 0)
 		}
 	}
 
 	// Draw grid.
-	if m.showGrid {
+	if vp.showGrid {
 		for y := 0; y < sm.Height; y++ {
-			xOffset := y * int(yStep.X)
-			yOffset := y * int(-yStep.Y)
 			col.A = 0
-			if m.showYGrids {
+			if vp.showYGrids || vp.viewMode == viewCrossXY || vp.viewMode == viewCrossZY {
 				// TODO: fade out based upon distance from focusedY
 				col.A = 15
 			}
-			if m.focusedY == y {
+			if vp.focusedY == y {
 				col.A = 50
 			}
-			for x := 0; x < sm.Width; x++ {
-				for z := 0; z < sm.Depth; z++ {
-					oX := pos.X + (x*tWidth+xOffset+startX)*scale
-					oY := pos.Y + (z*tHeight-yOffset+startY)*scale
+			x1, x2, z1, z2 := visibleTiles(y)
+			for x := x1; x <= x2; x++ {
+				for z := z1; z <= z2; z++ {
+					oX, oY := getTilePos(y, x, z)
 					oW := (tWidth) * scale
 					oH := (tHeight) * scale
+					if !isVisible(image.Rect(oX-pos.X, oY-pos.Y, oX-pos.X+oW, oY-pos.Y+oH)) {
+						continue
+					}
 					canvas.AddRect(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), col, 0, 0, 0.5)
 				}
 			}
@@ -332,7 +549,7 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 				continue
 			}
 
-			if y == lowestY {
+			if y == lowestY || !oblique {
 				drawRect(y, x, z, selectedBackgroundColor)
 			}
 
@@ -352,16 +569,46 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 		}
 	}
 
+	// Draw path test.
+	if m.path.v == v && m.path.hasStart {
+		if m.path.hasGoal {
+			route, _ := m.getPathRoute(v)
+			center := func(c [3]int) image.Point {
+				oX, oY := getTilePos(c[0], c[1], c[2])
+				return image.Pt(oX+tWidth*scale/2, oY+tHeight*scale/2)
+			}
+			for i := 1; i < len(route); i++ {
+				a, b := route[i-1], route[i]
+				if !m.isTileInView(vp, a[0], a[1], a[2]) || !m.isTileInView(vp, b[0], b[1], b[2]) {
+					continue
+				}
+				canvas.AddLine(center(a), center(b), pathColor, 2)
+			}
+			drawBox(m.path.goal[0], m.path.goal[1], m.path.goal[2], pathColor)
+		}
+		drawBox(m.path.start[0], m.path.start[1], m.path.start[2], pathColor)
+	}
+
+	// Draw symmetry axes along the focused Y level.
+	if m.symmetry.mode != symmetryNone && (oblique || vp.viewMode == viewTopDown) {
+		oW := tWidth * scale
+		oH := tHeight * scale
+		y := vp.focusedY
+		// Returns the screen position of a doubled axis position along a tile's edge.
+		axisPos := func(x2, z2 int) image.Point {
+			oX, oY := getTilePos(y, x2/2, z2/2)
+			return image.Pt(oX+x2%2*oW/2, oY+z2%2*oH/2)
+		}
+		if m.symmetry.mode != symmetryZ {
+			canvas.AddLine(axisPos(m.symmetry.axisX, 0), axisPos(m.symmetry.axisX, sm.Depth*2), symmetryAxisColor, 2)
+		}
+		if m.symmetry.mode != symmetryX {
+			canvas.AddLine(axisPos(0, m.symmetry.axisZ), axisPos(sm.Width*2, m.symmetry.axisZ), symmetryAxisColor, 2)
+		}
+	}
+
 	// Draw focused.
 	{
-		/*xOffset := m.focusedY * int(yStep.X)
-		yOffset := m.focusedY * int(-yStep.Y)
-		oX := pos.X + (m.focusedX*tWidth+xOffset+startX)*scale
-		oY := pos.Y + (m.focusedZ*tHeight-yOffset+startY)*scale
-		oW := (tWidth) * scale
-		oH := (tHeight) * scale
-
-		canvas.AddRect(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), focusedBorderColor, 0, 0, 1)*/
 		drawHeightBox(m.focusedY, m.focusedX, m.focusedZ, focusedHeightBoxColor)
 		drawBox(m.focusedY, m.focusedX, m.focusedZ, focusedBorderColor)
 	}
@@ -370,14 +617,5 @@ func (m *Mapset) drawMap(v *data.UnReMap) {
 	{
 		drawHeightBox(m.hoveredY, m.hoveredX, m.hoveredZ, hoveredHeightBoxColor)
 		drawBox(m.hoveredY, m.hoveredX, m.hoveredZ, hoveredBorderColor)
-		/*xOffset := m.hoveredY * int(yStep.X)
-		yOffset := m.hoveredY * int(-yStep.Y)
-		oX := pos.X + (m.hoveredX*tWidth+xOffset+startX)*scale
-		oY := pos.Y + (m.hoveredZ*tHeight-yOffset+startY)*scale
-		oW := (tWidth) * scale
-		oH := (tHeight) * scale
-
-		canvas.AddRect(image.Pt(oX, oY), image.Pt(oX+oW, oY+oH), hoveredBorderColor, 0, 0, 1)
-		*/
 	}
 }
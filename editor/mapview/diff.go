@@ -0,0 +1,24 @@
+package mapview
+
+import (
+	"github.com/chimera-rpg/go-editor/data"
+)
+
+// diffOverlay holds the kinds of tile changes shown over each map, by data name and then tile coordinates.
+type diffOverlay map[string]map[[3]int]int
+
+// ShowDiff overlays the tile changes of the given diffs on the mapset's maps. Passing nil clears the overlay.
+func (m *Mapset) ShowDiff(diffs []data.MapDiff) {
+	if diffs == nil {
+		m.diff = nil
+		return
+	}
+	m.diff = make(diffOverlay)
+	for _, d := range diffs {
+		tiles := make(map[[3]int]int)
+		for _, t := range d.Tiles {
+			tiles[[3]int{t.Y, t.X, t.Z}] = t.Kind
+		}
+		m.diff[d.Map] = tiles
+	}
+}
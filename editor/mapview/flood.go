@@ -0,0 +1,209 @@
+package mapview
+
+import (
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Ways flood fills compare a tile to the tile they started from.
+const (
+	floodMatchTop      = iota // The topmost archetypes are the same.
+	floodMatchStack           // Every archetype in the stacks is the same.
+	floodMatchAny             // The starting tile's topmost archetype is anywhere in the stack.
+	floodMatchAncestor        // The topmost archetypes share an ancestor other than a root archetype.
+	floodMatchType            // The topmost archetypes have the same ArchetypeType.
+)
+
+var floodMatchNames = []string{"Top Archetype", "Whole Stack", "Any Stack Member", "Same Ancestor", "Same Type"}
+
+// Neighbors that flood fills spread to.
+const (
+	floodConnectPlane         = iota // Left, right, forward and back on the X/Z plane.
+	floodConnectPlaneDiagonal        // As above plus diagonals on the X/Z plane.
+	floodConnect3D                   // As plane plus up and down.
+	floodConnect3DDiagonal           // Every tile touching in 3D.
+)
+
+var floodConnectNames = []string{"4-way X/Z", "8-way X/Z", "6-way 3D", "26-way 3D"}
+
+// floodOptions configures the magic wand and bucket fill.
+type floodOptions struct {
+	match        int
+	connectivity int
+}
+
+// neighbors returns the offsets the flood spreads to.
+func (o *floodOptions) neighbors() (offsets []Coords) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			for dz := -1; dz <= 1; dz++ {
+				if dy == 0 && dx == 0 && dz == 0 {
+					continue
+				}
+				axes := 0
+				for _, d := range []int{dy, dx, dz} {
+					if d != 0 {
+						axes++
+					}
+				}
+				switch o.connectivity {
+				case floodConnectPlane:
+					if dy != 0 || axes > 1 {
+						continue
+					}
+				case floodConnectPlaneDiagonal:
+					if dy != 0 {
+						continue
+					}
+				case floodConnect3D:
+					if axes > 1 {
+						continue
+					}
+				}
+				offsets = append(offsets, Coords{dy, dx, dz})
+			}
+		}
+	}
+	return
+}
+
+// sameArchetype returns whether two placed archetypes are based on the same archetypes.
+func sameArchetype(a, b *sdata.Archetype) bool {
+	if a.Arch != b.Arch || len(a.Archs) != len(b.Archs) {
+		return false
+	}
+	for i := range a.Archs {
+		if a.Archs[i] != b.Archs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// floodAncestors returns the ancestors of the archetype that the Same Ancestor mode compares. Root archetypes, which have no parents of their own, are left out since nearly everything shares them, unless the archetype has no other ancestors.
+func floodAncestors(dm *data.Manager, a *sdata.Archetype) map[string]bool {
+	all := archAncestors(dm, a)
+	ancestors := make(map[string]bool)
+	for _, name := range all {
+		if base := dm.GetArchetype(name); base == nil || len(archNames(base)) > 0 {
+			ancestors[name] = true
+		}
+	}
+	if len(ancestors) == 0 {
+		for _, name := range all {
+			ancestors[name] = true
+		}
+	}
+	return ancestors
+}
+
+// floodMatcher returns a function that reports whether a stack matches the starting stack.
+func (m *Mapset) floodMatcher(o floodOptions, start []sdata.Archetype) func(stack []sdata.Archetype) bool {
+	dm := m.context.DataManager()
+	var target *sdata.Archetype
+	var ancestors map[string]bool
+	if len(start) > 0 {
+		target = &start[len(start)-1]
+		if o.match == floodMatchAncestor {
+			ancestors = floodAncestors(dm, target)
+		}
+	}
+	// Every mode treats empty tiles as only matching each other.
+	return func(stack []sdata.Archetype) bool {
+		if target == nil || len(stack) == 0 {
+			return target == nil && len(stack) == 0
+		}
+		top := &stack[len(stack)-1]
+		switch o.match {
+		case floodMatchStack:
+			if len(stack) != len(start) {
+				return false
+			}
+			for i := range stack {
+				if !sameArchetype(&stack[i], &start[i]) {
+					return false
+				}
+			}
+			return true
+		case floodMatchAny:
+			for i := range stack {
+				if sameArchetype(&stack[i], target) {
+					return true
+				}
+			}
+			return false
+		case floodMatchAncestor:
+			for _, name := range archAncestors(dm, top) {
+				if ancestors[name] {
+					return true
+				}
+			}
+			return false
+		case floodMatchType:
+			return dm.GetArchType(top, 0) == dm.GetArchType(target, 0)
+		}
+		return sameArchetype(top, target)
+	}
+}
+
+// floodCoords returns the coordinates connected to the given tile that match it according to the options.
+func (m *Mapset) floodCoords(sm *sdata.Map, o floodOptions, y, x, z int) map[Coords]struct{} {
+	found := make(map[Coords]struct{})
+	start := m.getTiles(sm, y, x, z)
+	if start == nil {
+		return found
+	}
+	matches := m.floodMatcher(o, *start)
+	neighbors := o.neighbors()
+
+	visited := map[Coords]struct{}{{y, x, z}: {}}
+	queue := []Coords{{y, x, z}}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		tiles := m.getTiles(sm, c[0], c[1], c[2])
+		if tiles == nil || !matches(*tiles) {
+			continue
+		}
+		found[c] = struct{}{}
+		for _, n := range neighbors {
+			next := Coords{c[0] + n[0], c[1] + n[1], c[2] + n[2]}
+			if _, ok := visited[next]; ok {
+				continue
+			}
+			visited[next] = struct{}{}
+			queue = append(queue, next)
+		}
+	}
+	return found
+}
+
+func (m *Mapset) layoutFloodSettings() g.Widget {
+	o := &m.flood
+	return g.Custom(func() {
+		if m.isToolBound(fillTool) {
+			g.Checkbox("Bucket Fill", &m.bucketFill).Build()
+			g.Tooltip("Fill the tiles connected to the clicked one instead of the selection").Build()
+		}
+		if !m.isToolBound(wandTool) && !(m.isToolBound(fillTool) && m.bucketFill) {
+			return
+		}
+		g.Label("Match").Build()
+		for match, name := range floodMatchNames {
+			func(match int) {
+				g.Selectable(name + "##floodMatch").Selected(o.match == match).OnClick(func() {
+					o.match = match
+				}).Build()
+			}(match)
+		}
+		g.Label("Connectivity").Build()
+		for connectivity, name := range floodConnectNames {
+			func(connectivity int) {
+				g.Selectable(name + "##floodConnect").Selected(o.connectivity == connectivity).OnClick(func() {
+					o.connectivity = connectivity
+				}).Build()
+			}(connectivity)
+		}
+	})
+}
@@ -0,0 +1,449 @@
+package mapview
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/rand"
+
+	g "github.com/AllenDang/giu"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Procedural generators.
+const (
+	generateCaves = iota
+	generateDungeon
+	generateTunnels
+)
+
+var generatorNames = []string{"Caves", "Dungeon", "Tunnels"}
+
+// Cells of a generated layout.
+const (
+	cellOutside = iota // Not selected, left untouched.
+	cellWall
+	cellFloor
+	cellDoor
+)
+
+var generateWallColor = color.RGBA{96, 96, 96, 255}
+var generateFloorColor = color.RGBA{192, 176, 128, 255}
+var generateDoorColor = color.RGBA{160, 64, 0, 255}
+
+const generatePreviewSize = 320
+
+// generatorParams are the settings of a generator. A change to any of them regenerates the preview.
+type generatorParams struct {
+	kind       int
+	seed       int32
+	density    int32 // Caves: percent of cells starting as walls.
+	iterations int32 // Caves: smoothing passes.
+	roomSize   int32 // Dungeon: smallest room width or depth.
+	doorChance int32 // Dungeon: percent of room entrances given a door.
+	coverage   int32 // Tunnels: percent of cells to carve.
+	walkers    int32 // Tunnels: number of walks started from the center.
+}
+
+// generator holds the state of the Generate dialog.
+type generator struct {
+	params                        generatorParams
+	previewParams                 *generatorParams
+	wallArch, floorArch, doorArch string
+	floorUnderWalls               bool
+	replace                       bool
+	columns                       map[[2]int][]int // Selected Ys of each selected X and Z column.
+	minX, minZ, width, depth      int
+	cells                         [][]int // Generated layout by X then Z, relative to minX and minZ.
+	err                           error
+}
+
+// openGenerator prepares the Generate dialog for the given generator and the current selection.
+func (m *Mapset) openGenerator(kind int) {
+	gen := &m.generator
+	if gen.params == (generatorParams{}) {
+		gen.params = generatorParams{
+			seed:       rand.Int31(),
+			density:    45,
+			iterations: 5,
+			roomSize:   4,
+			doorChance: 50,
+			coverage:   40,
+			walkers:    1,
+		}
+		gen.floorUnderWalls = true
+		gen.replace = true
+	}
+	gen.params.kind = kind
+	gen.previewParams = nil
+	gen.err = nil
+	gen.columns = make(map[[2]int][]int)
+	first := true
+	maxX, maxZ := 0, 0
+	for c := range m.selectedCoords.Get() {
+		y, x, z := c[0], c[1], c[2]
+		gen.columns[[2]int{x, z}] = append(gen.columns[[2]int{x, z}], y)
+		if first || x < gen.minX {
+			gen.minX = x
+		}
+		if first || z < gen.minZ {
+			gen.minZ = z
+		}
+		if first || x > maxX {
+			maxX = x
+		}
+		if first || z > maxZ {
+			maxZ = z
+		}
+		first = false
+	}
+	gen.width, gen.depth = maxX-gen.minX+1, maxZ-gen.minZ+1
+	if gen.floorArch == "" {
+		gen.floorArch = m.context.SelectedArch()
+	}
+}
+
+// newCells returns a layout with every selected cell set to the given cell.
+func (gen *generator) newCells(cell int) [][]int {
+	cells := make([][]int, gen.width)
+	for x := range cells {
+		cells[x] = make([]int, gen.depth)
+		for z := range cells[x] {
+			if _, ok := gen.columns[[2]int{gen.minX + x, gen.minZ + z}]; ok {
+				cells[x][z] = cell
+			}
+		}
+	}
+	return cells
+}
+
+// isOpenCell returns whether the cell is selected and not a wall.
+func isOpenCell(cells [][]int, x, z int) bool {
+	return x >= 0 && z >= 0 && x < len(cells) && z < len(cells[x]) && (cells[x][z] == cellFloor || cells[x][z] == cellDoor)
+}
+
+// generate regenerates the layout if the parameters have changed.
+func (gen *generator) generate() {
+	if gen.previewParams != nil && *gen.previewParams == gen.params {
+		return
+	}
+	p := gen.params
+	gen.previewParams = &p
+	r := rand.New(rand.NewSource(int64(p.seed)))
+	switch p.kind {
+	case generateCaves:
+		gen.cells = gen.generateCaves(r, p)
+	case generateDungeon:
+		gen.cells = gen.generateDungeon(r, p)
+	case generateTunnels:
+		gen.cells = gen.generateTunnels(r, p)
+	}
+}
+
+// generateCaves fills the cells with random walls then smooths them with a cellular automaton, where a cell becomes a wall if most of its neighbors are walls. Cells beyond the edges of the area count as walls.
+func (gen *generator) generateCaves(r *rand.Rand, p generatorParams) [][]int {
+	cells := gen.newCells(cellFloor)
+	isWall := func(cells [][]int, x, z int) bool {
+		return !isOpenCell(cells, x, z)
+	}
+	for x := range cells {
+		for z := range cells[x] {
+			if cells[x][z] != cellOutside && (r.Int31n(100) < p.density || x == 0 || z == 0 || x == gen.width-1 || z == gen.depth-1) {
+				cells[x][z] = cellWall
+			}
+		}
+	}
+	for i := int32(0); i < p.iterations; i++ {
+		next := gen.newCells(cellFloor)
+		for x := range cells {
+			for z := range cells[x] {
+				if cells[x][z] == cellOutside {
+					continue
+				}
+				walls := 0
+				for dx := -1; dx <= 1; dx++ {
+					for dz := -1; dz <= 1; dz++ {
+						if (dx != 0 || dz != 0) && isWall(cells, x+dx, z+dz) {
+							walls++
+						}
+					}
+				}
+				if walls >= 5 || (walls >= 4 && cells[x][z] == cellWall) {
+					next[x][z] = cellWall
+				}
+			}
+		}
+		cells = next
+	}
+	return cells
+}
+
+// generateDungeon splits the area in two repeatedly, places a room in each part, and joins sibling parts with corridors. Corridors entering a room may be given a door.
+func (gen *generator) generateDungeon(r *rand.Rand, p generatorParams) [][]int {
+	cells := gen.newCells(cellWall)
+	minSize := int(p.roomSize)
+	if minSize < 1 {
+		minSize = 1
+	}
+	rooms := make(map[[2]int]bool)
+	carve := func(x, z int, room bool) {
+		if x >= 0 && z >= 0 && x < gen.width && z < gen.depth && cells[x][z] != cellOutside {
+			cells[x][z] = cellFloor
+			if room {
+				rooms[[2]int{x, z}] = true
+			}
+		}
+	}
+	// split returns the center of a room within the part.
+	var split func(area image.Rectangle) image.Point
+	split = func(area image.Rectangle) image.Point {
+		// A part needs room for two rooms and their surrounding walls to be split.
+		canSplitX, canSplitZ := area.Dx() >= (minSize+2)*2, area.Dy() >= (minSize+2)*2
+		if canSplitX || canSplitZ {
+			var a, b image.Rectangle
+			if canSplitX && (!canSplitZ || area.Dx() > area.Dy() || (area.Dx() == area.Dy() && r.Intn(2) == 0)) {
+				at := area.Min.X + minSize + 2 + r.Intn(area.Dx()-(minSize+2)*2+1)
+				a, b = image.Rect(area.Min.X, area.Min.Y, at, area.Max.Y), image.Rect(at, area.Min.Y, area.Max.X, area.Max.Y)
+			} else {
+				at := area.Min.Y + minSize + 2 + r.Intn(area.Dy()-(minSize+2)*2+1)
+				a, b = image.Rect(area.Min.X, area.Min.Y, area.Max.X, at), image.Rect(area.Min.X, at, area.Max.X, area.Max.Y)
+			}
+			ca, cb := split(a), split(b)
+			// Join the parts with an L shaped corridor.
+			for x := minInt(ca.X, cb.X); x <= maxInt(ca.X, cb.X); x++ {
+				carve(x, ca.Y, false)
+			}
+			for z := minInt(ca.Y, cb.Y); z <= maxInt(ca.Y, cb.Y); z++ {
+				carve(cb.X, z, false)
+			}
+			if r.Intn(2) == 0 {
+				return ca
+			}
+			return cb
+		}
+		// Place a room of random size, leaving a wall on each side.
+		w := minSize + r.Intn(maxInt(area.Dx()-2-minSize, 0)+1)
+		d := minSize + r.Intn(maxInt(area.Dy()-2-minSize, 0)+1)
+		x1 := area.Min.X + 1 + r.Intn(maxInt(area.Dx()-2-w, 0)+1)
+		z1 := area.Min.Y + 1 + r.Intn(maxInt(area.Dy()-2-d, 0)+1)
+		for x := x1; x < x1+w && x < area.Max.X-1; x++ {
+			for z := z1; z < z1+d && z < area.Max.Y-1; z++ {
+				carve(x, z, true)
+			}
+		}
+		return image.Pt(x1+w/2, z1+d/2)
+	}
+	split(image.Rect(0, 0, gen.width, gen.depth))
+
+	// Corridor cells next to a room and between two walls are entrances.
+	for x := range cells {
+		for z := range cells[x] {
+			if cells[x][z] != cellFloor || rooms[[2]int{x, z}] {
+				continue
+			}
+			nextToRoom := rooms[[2]int{x - 1, z}] || rooms[[2]int{x + 1, z}] || rooms[[2]int{x, z - 1}] || rooms[[2]int{x, z + 1}]
+			betweenX := !isOpenCell(cells, x-1, z) && !isOpenCell(cells, x+1, z)
+			betweenZ := !isOpenCell(cells, x, z-1) && !isOpenCell(cells, x, z+1)
+			if nextToRoom && (betweenX || betweenZ) && r.Int31n(100) < p.doorChance {
+				cells[x][z] = cellDoor
+			}
+		}
+	}
+	return cells
+}
+
+// generateTunnels carves tunnels by walking randomly from the center of the area until enough of it is carved.
+func (gen *generator) generateTunnels(r *rand.Rand, p generatorParams) [][]int {
+	cells := gen.newCells(cellWall)
+	total := 0
+	for x := range cells {
+		for z := range cells[x] {
+			if cells[x][z] != cellOutside {
+				total++
+			}
+		}
+	}
+	target := total * int(p.coverage) / 100
+	walkers := int(p.walkers)
+	if walkers < 1 {
+		walkers = 1
+	}
+	carved := 0
+	steps := total * 50 // Give up eventually if the selection is oddly shaped.
+	for w := 0; w < walkers; w++ {
+		x, z := gen.width/2, gen.depth/2
+		for carved < target*(w+1)/walkers && steps > 0 {
+			steps--
+			if x >= 0 && z >= 0 && x < gen.width && z < gen.depth && cells[x][z] == cellWall {
+				cells[x][z] = cellFloor
+				carved++
+			}
+			nx, nz := x, z
+			switch r.Intn(4) {
+			case 0:
+				nx++
+			case 1:
+				nx--
+			case 2:
+				nz++
+			case 3:
+				nz--
+			}
+			// Stay within the edges of the area.
+			if nx > 0 && nz > 0 && nx < gen.width-1 && nz < gen.depth-1 {
+				x, z = nx, nz
+			}
+		}
+	}
+	return cells
+}
+
+// applyGenerator places the generated layout into the current map as a single undoable change. The layout is flat, so each cell is placed at every selected Y of its column.
+func (m *Mapset) applyGenerator() error {
+	gen := &m.generator
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	gen.generate()
+	clone := cm.Clone()
+	for c, ys := range gen.columns {
+		x, z := c[0], c[1]
+		var archs []string
+		switch gen.cells[x-gen.minX][z-gen.minZ] {
+		case cellWall:
+			if gen.floorUnderWalls {
+				archs = append(archs, gen.floorArch)
+			}
+			archs = append(archs, gen.wallArch)
+		case cellFloor:
+			archs = append(archs, gen.floorArch)
+		case cellDoor:
+			archs = append(archs, gen.floorArch, gen.doorArch)
+		}
+		for _, y := range ys {
+			tiles := m.getTiles(clone, y, x, z)
+			if tiles == nil {
+				continue
+			}
+			if gen.replace {
+				*tiles = []sdata.Archetype{}
+			}
+			for _, arch := range archs {
+				if arch != "" {
+					*tiles = append(*tiles, sdata.Archetype{Archs: []string{arch}})
+				}
+			}
+		}
+	}
+	cm.Set(clone)
+	m.selectArchetype()
+	return nil
+}
+
+func (m *Mapset) layoutGeneratePreview() g.Widget {
+	gen := &m.generator
+	return g.Custom(func() {
+		gen.generate()
+		if gen.width <= 0 || gen.depth <= 0 {
+			return
+		}
+		cell := generatePreviewSize / maxInt(gen.width, gen.depth)
+		if cell < 1 {
+			cell = 1
+		}
+		pos := g.GetCursorScreenPos()
+		canvas := g.GetCanvas()
+		for x := range gen.cells {
+			for z := range gen.cells[x] {
+				var c color.RGBA
+				switch gen.cells[x][z] {
+				case cellWall:
+					c = generateWallColor
+				case cellFloor:
+					c = generateFloorColor
+				case cellDoor:
+					c = generateDoorColor
+				default:
+					continue
+				}
+				p := pos.Add(image.Pt(x*cell, z*cell))
+				canvas.AddRectFilled(p, p.Add(image.Pt(cell, cell)), c, 0, 0)
+			}
+		}
+		g.Dummy(float32(gen.width*cell), float32(gen.depth*cell)).Build()
+	})
+}
+
+func (m *Mapset) layoutGeneratePopup() g.Widget {
+	gen := &m.generator
+	return g.PopupModal("Generate").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Custom(func() {
+			g.Label(generatorNames[gen.params.kind]).Build()
+			g.Tooltip("The layout is generated across X and Z and placed at every selected Y").Build()
+			g.Row(
+				g.InputInt(&gen.params.seed).Label("Seed"),
+				g.Button("Random").OnClick(func() {
+					gen.params.seed = rand.Int31()
+				}),
+			).Build()
+			switch gen.params.kind {
+			case generateCaves:
+				g.SliderInt(&gen.params.density, 0, 100).Label("Wall Density").Format("%d%%").Build()
+				g.SliderInt(&gen.params.iterations, 0, 10).Label("Smoothing").Format("%d").Build()
+			case generateDungeon:
+				g.SliderInt(&gen.params.roomSize, 1, 20).Label("Room Size").Format("%d").Build()
+				g.SliderInt(&gen.params.doorChance, 0, 100).Label("Door Chance").Format("%d%%").Build()
+			case generateTunnels:
+				g.SliderInt(&gen.params.coverage, 1, 100).Label("Coverage").Format("%d%%").Build()
+				g.SliderInt(&gen.params.walkers, 1, 10).Label("Walkers").Format("%d").Build()
+			}
+			archRow := func(arch *string, label string) g.Widget {
+				return g.Row(
+					g.InputText(arch).Label(label),
+					g.Button("Use Selected##"+label).OnClick(func() {
+						*arch = m.context.SelectedArch()
+					}),
+				)
+			}
+			archRow(&gen.wallArch, "Wall").Build()
+			archRow(&gen.floorArch, "Floor").Build()
+			if gen.params.kind == generateDungeon {
+				archRow(&gen.doorArch, "Door").Build()
+			}
+			g.Checkbox("Floor Under Walls", &gen.floorUnderWalls).Build()
+			g.Checkbox("Replace Existing", &gen.replace).Build()
+			g.Tooltip("Clear the selected tiles before placing the generated archetypes").Build()
+		}),
+		m.layoutGeneratePreview(),
+		g.Custom(func() {
+			if gen.err != nil {
+				g.Label(gen.err.Error()).Build()
+			}
+		}),
+		g.Row(
+			g.Button("Apply").OnClick(func() {
+				if gen.err = m.applyGenerator(); gen.err == nil {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
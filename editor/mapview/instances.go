@@ -0,0 +1,80 @@
+package mapview
+
+import (
+	"sort"
+	"strings"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// instanceSelection chooses the placed archetypes in the selection that are edited together in the archetype editor.
+type instanceSelection struct {
+	active bool
+	filter string // Only archetypes whose archetype name contains this are edited.
+}
+
+// matches returns whether the placed archetype passes the filter.
+func (s *instanceSelection) matches(a *sdata.Archetype) bool {
+	if s.filter == "" {
+		return true
+	}
+	if strings.Contains(a.Arch, s.filter) {
+		return true
+	}
+	for _, name := range a.Archs {
+		if strings.Contains(name, s.filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectInstances returns the matching archetypes in the selected tiles of the current map, ordered by coordinate and then stack position.
+func (m *Mapset) collectInstances() (archs []*sdata.Archetype) {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return
+	}
+	sm := cm.Get()
+	var coords []Coords
+	for c := range m.selectedCoords.Get() {
+		coords = append(coords, c)
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		for k := range coords[i] {
+			if coords[i][k] != coords[j][k] {
+				return coords[i][k] < coords[j][k]
+			}
+		}
+		return false
+	})
+	for _, c := range coords {
+		tiles := m.getTiles(sm, c[0], c[1], c[2])
+		if tiles == nil {
+			continue
+		}
+		for i := range *tiles {
+			if m.instances.matches(&(*tiles)[i]) {
+				archs = append(archs, &(*tiles)[i])
+			}
+		}
+	}
+	return
+}
+
+// useFocusedInstanceFilter filters instances by the archetype name of the focused archetype.
+func (m *Mapset) useFocusedInstanceFilter() {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return
+	}
+	archs := cm.GetArchs(m.focusedY, m.focusedX, m.focusedZ)
+	if m.focusedI < 0 || m.focusedI >= len(archs) {
+		return
+	}
+	a := archs[m.focusedI]
+	m.instances.filter = a.Arch
+	if m.instances.filter == "" && len(a.Archs) > 0 {
+		m.instances.filter = a.Archs[0]
+	}
+}
@@ -0,0 +1,100 @@
+package mapview
+
+import (
+	"math"
+
+	"github.com/chimera-rpg/go-editor/data"
+)
+
+// maxDarkness is the map Darkness value at which there is no ambient light.
+const maxDarkness = 100
+
+// pitchBlackLevel is the light level at or below which a player cannot see.
+const pitchBlackLevel = 0.05
+
+// lightMap holds the computed light level of every tile in a map for a given revision.
+type lightMap struct {
+	v        *data.UnReMap
+	revision int
+	valid    bool
+	levels   [][][]float64 // [y][x][z] light levels from 0 to 1.
+}
+
+// Invalidate forces the light map to be recomputed.
+func (l *lightMap) Invalidate() {
+	l.valid = false
+	l.levels = nil
+}
+
+// Get returns the light level at the given coordinates.
+func (l *lightMap) Get(y, x, z int) float64 {
+	if y < 0 || y >= len(l.levels) || x < 0 || x >= len(l.levels[y]) || z < 0 || z >= len(l.levels[y][x]) {
+		return 0
+	}
+	return l.levels[y][x][z]
+}
+
+// getLightMap returns the light map for the given map, recomputing it if the map has changed.
+func (m *Mapset) getLightMap(v *data.UnReMap) *lightMap {
+	if m.lights.valid && m.lights.v == v && m.lights.revision == v.Revision() {
+		return &m.lights
+	}
+	dm := m.context.DataManager()
+	sm := v.Get()
+
+	// Start everything at the map's ambient light.
+	ambient := 1 - float64(sm.Darkness)/maxDarkness
+	ambient = math.Max(0, math.Min(1, ambient))
+	levels := make([][][]float64, sm.Height)
+	for y := 0; y < sm.Height; y++ {
+		levels[y] = make([][]float64, sm.Width)
+		for x := 0; x < sm.Width; x++ {
+			levels[y][x] = make([]float64, sm.Depth)
+			for z := 0; z < sm.Depth; z++ {
+				levels[y][x][z] = ambient
+			}
+		}
+	}
+
+	// Add the contribution of every light-emitting archetype, falling off linearly over its radius.
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				for t := range sm.Tiles[y][x][z] {
+					intensity, radius := dm.GetArchLight(&sm.Tiles[y][x][z][t])
+					if radius <= 0 {
+						continue
+					}
+					for ly := y - radius; ly <= y+radius; ly++ {
+						if ly < 0 || ly >= sm.Height {
+							continue
+						}
+						for lx := x - radius; lx <= x+radius; lx++ {
+							if lx < 0 || lx >= sm.Width {
+								continue
+							}
+							for lz := z - radius; lz <= z+radius; lz++ {
+								if lz < 0 || lz >= sm.Depth {
+									continue
+								}
+								dist := math.Sqrt(float64((ly-y)*(ly-y) + (lx-x)*(lx-x) + (lz-z)*(lz-z)))
+								if dist > float64(radius) {
+									continue
+								}
+								levels[ly][lx][lz] = math.Min(1, levels[ly][lx][lz]+intensity*(1-dist/float64(radius+1)))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	m.lights = lightMap{
+		v:        v,
+		revision: v.Revision(),
+		valid:    true,
+		levels:   levels,
+	}
+	return &m.lights
+}
@@ -0,0 +1,252 @@
+package mapview
+
+import (
+	"errors"
+	"fmt"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Operations that derive maps from other maps in the mapset.
+const (
+	mapOpDuplicate = iota
+	mapOpSplit
+	mapOpMerge
+)
+
+var mapOpNames = []string{"Duplicate Map", "Split Selection", "Merge Map"}
+
+// mapOperation holds the state of the dialog for deriving maps.
+type mapOperation struct {
+	kind             int
+	dataName         string // Data name of the new map for duplicate and split.
+	source           string // Data name of the map merged into the current one.
+	offY, offX, offZ int32
+	replace          bool // Whether merged tiles replace the existing ones rather than stacking on top.
+	err              error
+}
+
+// openMapOperation prepares the dialog for the given operation on the current map.
+func (m *Mapset) openMapOperation(kind int) {
+	m.mapOp.kind = kind
+	m.mapOp.err = nil
+	m.mapOp.dataName = ""
+	if cm := m.CurrentMap(); cm != nil && kind == mapOpDuplicate {
+		m.mapOp.dataName = cm.DataName() + "-copy"
+	}
+}
+
+// checkNewDataName returns an error if the data name can't be used for a new map.
+func (m *Mapset) checkNewDataName(dataName string) error {
+	if dataName == "" {
+		return errors.New("a data name is required")
+	}
+	if m.Map(dataName) != nil {
+		return fmt.Errorf("a map named %s already exists", dataName)
+	}
+	return nil
+}
+
+// extractRegion copies the tiles between min and max, inclusive, into a new map with the same properties. If only is non-nil, just those tiles are copied. The new map's position is offset so it stays where the region was.
+func (m *Mapset) extractRegion(sm *sdata.Map, min, max Coords, only *SelectedCoords) *sdata.Map {
+	newMap := m.newMapLike(sm, max[0]-min[0]+1, max[1]-min[1]+1, max[2]-min[2]+1)
+	newMap.Y += min[0]
+	newMap.X += min[1]
+	newMap.Z += min[2]
+	for y := min[0]; y <= max[0]; y++ {
+		for x := min[1]; x <= max[1]; x++ {
+			for z := min[2]; z <= max[2]; z++ {
+				if only != nil && only.Unselected(y, x, z) {
+					continue
+				}
+				if tiles := m.getTiles(sm, y, x, z); tiles != nil {
+					newMap.Tiles[y-min[0]][x-min[1]][z-min[2]] = append([]sdata.Archetype{}, (*tiles)...)
+				}
+			}
+		}
+	}
+	return newMap
+}
+
+// clippedSelectionBounds returns the bounds of the selection within the map.
+func (m *Mapset) clippedSelectionBounds(sm *sdata.Map) (min, max Coords, err error) {
+	if m.selectedCoords.Empty() {
+		return min, max, errors.New("nothing is selected")
+	}
+	min, max = m.selectionBounds()
+	size := Coords{sm.Height, sm.Width, sm.Depth}
+	for i := range min {
+		min[i] = maxInt(min[i], 0)
+		max[i] = minInt(max[i], size[i]-1)
+		if min[i] > max[i] {
+			return min, max, errors.New("the selection is outside of the map")
+		}
+	}
+	return
+}
+
+// cropMap shrinks the current map to the bounds of the selection as a single undo step.
+func (m *Mapset) cropMap() error {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	min, max, err := m.clippedSelectionBounds(cm.Get())
+	if err != nil {
+		return err
+	}
+	cm.Set(m.extractRegion(cm.Get(), min, max, nil))
+	m.moveCursor(maxInt(m.focusedY-min[0], 0), maxInt(m.focusedX-min[1], 0), maxInt(m.focusedZ-min[2], 0), m.focusedI)
+	m.ensure()
+	return nil
+}
+
+// duplicateMap adds a copy of the current map under a new data name.
+func (m *Mapset) duplicateMap(dataName string) error {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	if err := m.checkNewDataName(dataName); err != nil {
+		return err
+	}
+	m.SetMap(dataName, cm.Clone())
+	m.SelectMap(dataName)
+	return nil
+}
+
+// splitSelection cuts the selected tiles out of the current map, as a single undo step, into a new map sized to the selection.
+func (m *Mapset) splitSelection(dataName string) error {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	if err := m.checkNewDataName(dataName); err != nil {
+		return err
+	}
+	min, max, err := m.clippedSelectionBounds(cm.Get())
+	if err != nil {
+		return err
+	}
+	newMap := m.extractRegion(cm.Get(), min, max, &m.selectedCoords)
+
+	clone := cm.Clone()
+	for c := range m.selectedCoords.Get() {
+		if tiles := m.getTiles(clone, c[0], c[1], c[2]); tiles != nil {
+			*tiles = []sdata.Archetype{}
+		}
+	}
+	cm.Set(clone)
+
+	m.SetMap(dataName, newMap)
+	m.SelectMap(dataName)
+	return nil
+}
+
+// mergeMap pastes the non-empty tiles of another map in the mapset into the current map at the given offset as a single undo step. Tiles that fall outside of the current map are dropped.
+func (m *Mapset) mergeMap(source string, offY, offX, offZ int, replace bool) error {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	sv := m.Map(source)
+	if sv == nil {
+		return fmt.Errorf("no map named %s", source)
+	}
+	if sv == cm {
+		return errors.New("a map can't be merged into itself")
+	}
+	sm := sv.Get()
+	clone := cm.Clone()
+	changed := false
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				from := sm.Tiles[y][x][z]
+				if len(from) == 0 {
+					continue
+				}
+				to := m.getTiles(clone, y+offY, x+offX, z+offZ)
+				if to == nil {
+					continue
+				}
+				if replace {
+					*to = append([]sdata.Archetype{}, from...)
+				} else {
+					*to = append(*to, from...)
+				}
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return errors.New("no tiles of the map fall within the current map")
+	}
+	cm.Set(clone)
+	m.selectArchetype()
+	return nil
+}
+
+// applyMapOperation runs the operation chosen in the dialog.
+func (m *Mapset) applyMapOperation() error {
+	op := &m.mapOp
+	switch op.kind {
+	case mapOpDuplicate:
+		return m.duplicateMap(op.dataName)
+	case mapOpSplit:
+		return m.splitSelection(op.dataName)
+	case mapOpMerge:
+		return m.mergeMap(op.source, int(op.offY), int(op.offX), int(op.offZ), op.replace)
+	}
+	return nil
+}
+
+func (m *Mapset) layoutMapOperationPopup() g.Widget {
+	op := &m.mapOp
+	return g.PopupModal("Map Operation").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Custom(func() {
+			g.Label(mapOpNames[op.kind]).Build()
+			switch op.kind {
+			case mapOpDuplicate, mapOpSplit:
+				g.InputText(&op.dataName).Label("Data Name").Build()
+			case mapOpMerge:
+				var items g.Layout
+				cm := m.CurrentMap()
+				for _, v := range m.maps {
+					if v == cm {
+						continue
+					}
+					func(v *data.UnReMap) {
+						items = append(items, g.Selectable(v.DataName()).Selected(v.DataName() == op.source).OnClick(func() {
+							op.source = v.DataName()
+						}))
+					}(v)
+				}
+				g.Child().Border(true).Size(300, 150).Layout(items).Build()
+				g.Row(
+					g.InputInt(&op.offY).Size(50).Label("Y"),
+					g.InputInt(&op.offX).Size(50).Label("X"),
+					g.InputInt(&op.offZ).Size(50).Label("Z"),
+				).Build()
+				g.Tooltip("Where the merged map's origin goes in the current map.").Build()
+				g.Checkbox("Replace Tiles", &op.replace).Build()
+				g.Tooltip("Replace existing tiles instead of stacking the merged archetypes on top.").Build()
+			}
+			if op.err != nil {
+				g.Label(op.err.Error()).Build()
+			}
+		}),
+		g.Row(
+			g.Button("Apply").OnClick(func() {
+				if op.err = m.applyMapOperation(); op.err == nil {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
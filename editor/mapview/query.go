@@ -0,0 +1,467 @@
+package mapview
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+	"gopkg.in/yaml.v2"
+)
+
+// queryTile is the tile a query is matched against.
+type queryTile struct {
+	y, x, z int
+	stack   []sdata.Archetype
+	dm      *data.Manager
+}
+
+// queryFunc reports whether a tile matches a query.
+type queryFunc func(t *queryTile) bool
+
+// queryHelp describes the query syntax in the Select By dialog.
+var queryHelp = []string{
+	"Terms are joined with and, or, not and parentheses, e.g. type = Tile and y >= 2",
+	"Operators: =  !=  <  <=  >  >=  ~ (contains)",
+	"y, x, z       coordinates of the tile",
+	"height        number of archetypes in the stack",
+	"arch          archetype name of any archetype in the stack",
+	"ancestor      any archetype the stack's archetypes inherit from",
+	"top           archetype name of the topmost archetype",
+	"type          ArchetypeType of any archetype in the stack",
+	"field.<Name>  value of a field of any archetype in the stack, e.g. field.Name ~ door",
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+// lexQuery splits a query into words, quoted strings, operators and parentheses.
+func lexQuery(s string) (tokens []queryToken, err error) {
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == '~':
+			tokens = append(tokens, queryToken{text: string(c), pos: i})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			if i+1 < len(r) && r[i+1] == '=' {
+				tokens = append(tokens, queryToken{text: string(r[i : i+2]), pos: i})
+				i += 2
+			} else if c == '!' {
+				return nil, fmt.Errorf("expected != at %d", i+1)
+			} else {
+				tokens = append(tokens, queryToken{text: string(c), pos: i})
+				i++
+			}
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(r) && r[end] != c {
+				end++
+			}
+			if end >= len(r) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			tokens = append(tokens, queryToken{text: string(r[i+1 : end]), quoted: true, pos: i})
+			i = end + 1
+		default:
+			start := i
+			for i < len(r) && !unicode.IsSpace(r[i]) && !strings.ContainsRune("()~=!<>\"'", r[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{text: string(r[start:i]), pos: start})
+		}
+	}
+	return
+}
+
+// queryParser is a recursive descent parser for Select By queries.
+type queryParser struct {
+	tokens []queryToken
+	i      int
+}
+
+// parseQuery compiles a query into a function that matches tiles.
+func parseQuery(s string) (queryFunc, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the query is empty")
+	}
+	p := &queryParser{tokens: tokens}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.tokens[p.i].text, p.tokens[p.i].pos+1)
+	}
+	return q, nil
+}
+
+func (p *queryParser) peekKeyword(word string) bool {
+	return p.i < len(p.tokens) && !p.tokens[p.i].quoted && strings.EqualFold(p.tokens[p.i].text, word)
+}
+
+func (p *queryParser) next() (queryToken, error) {
+	if p.i >= len(p.tokens) {
+		return queryToken{}, fmt.Errorf("the query ends too early")
+	}
+	p.i++
+	return p.tokens[p.i-1], nil
+}
+
+func (p *queryParser) parseOr() (queryFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.i++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(t *queryTile) bool { return a(t) || b(t) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryFunc, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.i++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(t *queryTile) bool { return a(t) && b(t) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryFunc, error) {
+	if p.peekKeyword("not") {
+		p.i++
+		q, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *queryTile) bool { return !q(t) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryFunc, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.text == "(" && !tok.quoted {
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end, err := p.next(); err != nil || end.text != ")" {
+			return nil, fmt.Errorf("missing ) for ( at %d", tok.pos+1)
+		}
+		return q, nil
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "=", "!=", "<", "<=", ">", ">=", "~":
+	default:
+		return nil, fmt.Errorf("expected an operator after %s at %d", tok.text, op.pos+1)
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return compileTerm(strings.ToLower(tok.text), tok.text, op.text, value.text)
+}
+
+// compileTerm returns the function for a single key, operator and value term.
+func compileTerm(key, rawKey, op, value string) (queryFunc, error) {
+	// != matches tiles where = does not, so "arch != wall" means no wall in the stack.
+	if op == "!=" {
+		q, err := compileTerm(key, rawKey, "=", value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *queryTile) bool { return !q(t) }, nil
+	}
+
+	switch key {
+	case "y", "x", "z", "height":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number, not %q", key, value)
+		}
+		if op == "~" {
+			return nil, fmt.Errorf("%s can't use ~", key)
+		}
+		get := map[string]func(t *queryTile) int{
+			"y":      func(t *queryTile) int { return t.y },
+			"x":      func(t *queryTile) int { return t.x },
+			"z":      func(t *queryTile) int { return t.z },
+			"height": func(t *queryTile) int { return len(t.stack) },
+		}[key]
+		return func(t *queryTile) bool { return compareNumbers(float64(get(t)), op, float64(n)) }, nil
+	case "arch":
+		return anyArch(func(t *queryTile, a *sdata.Archetype) bool {
+			for _, name := range archNames(a) {
+				if compareStrings(name, op, value) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "ancestor":
+		return anyArch(func(t *queryTile, a *sdata.Archetype) bool {
+			for _, name := range archAncestors(t.dm, a) {
+				if compareStrings(name, op, value) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "top":
+		return func(t *queryTile) bool {
+			if len(t.stack) == 0 {
+				return false
+			}
+			for _, name := range archNames(&t.stack[len(t.stack)-1]) {
+				if compareStrings(name, op, value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "type":
+		return anyArch(func(t *queryTile, a *sdata.Archetype) bool {
+			atype := t.dm.GetArchType(a, 0)
+			if n, err := strconv.Atoi(value); err == nil {
+				return compareNumbers(float64(atype), op, float64(n))
+			}
+			return compareStrings(strings.ToLower(describeValue(reflect.ValueOf(atype))), op, strings.ToLower(value))
+		}), nil
+	}
+
+	if strings.HasPrefix(key, "field.") {
+		field := rawKey[len("field."):]
+		if _, ok := reflect.TypeOf(sdata.Archetype{}).FieldByName(field); !ok {
+			return nil, fmt.Errorf("archetypes have no field %s", field)
+		}
+		return anyArch(func(t *queryTile, a *sdata.Archetype) bool {
+			v := t.dm.GetArchField(a, field)
+			for v.IsValid() && v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return false
+				}
+				v = v.Elem()
+			}
+			if !v.IsValid() {
+				return false
+			}
+			s := describeValue(v)
+			if op != "~" {
+				a, errA := strconv.ParseFloat(s, 64)
+				b, errB := strconv.ParseFloat(value, 64)
+				if errA == nil && errB == nil {
+					return compareNumbers(a, op, b)
+				}
+			}
+			return compareStrings(s, op, value)
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown key %s", rawKey)
+}
+
+// anyArch matches tiles where any archetype in the stack matches.
+func anyArch(match func(t *queryTile, a *sdata.Archetype) bool) queryFunc {
+	return func(t *queryTile) bool {
+		for i := range t.stack {
+			if match(t, &t.stack[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// archNames returns the archetype names a placed archetype is based on.
+func archNames(a *sdata.Archetype) []string {
+	names := append([]string{}, a.Archs...)
+	if a.Arch != "" {
+		names = append(names, a.Arch)
+	}
+	return names
+}
+
+// archAncestors returns the names of every archetype the archetype inherits from.
+func archAncestors(dm *data.Manager, a *sdata.Archetype) (names []string) {
+	seen := make(map[string]bool)
+	pending := archNames(a)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if parent := dm.GetArchetype(name); parent != nil {
+			pending = append(pending, archNames(parent)...)
+		}
+	}
+	return
+}
+
+// describeValue formats a value the way it is written in archetype files.
+func describeValue(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if b, err := yaml.Marshal(v.Interface()); err == nil {
+		return strings.TrimSpace(string(b))
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func compareNumbers(a float64, op string, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func compareStrings(a, op, b string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "~":
+		return strings.Contains(a, b)
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// Ways Select By combines its matches with the selection, as in toolSelect.
+const (
+	selectByReplace = iota
+	selectByAdd
+	selectBySubtract
+)
+
+var selectByModeNames = []string{"Replace", "Add", "Subtract"}
+
+// selectBy holds the state of the Select By dialog.
+type selectBy struct {
+	query string
+	mode  int
+	err   error
+}
+
+// applySelectBy selects every tile of the current map that matches the query.
+func (m *Mapset) applySelectBy() error {
+	s := &m.selectBy
+	cm := m.CurrentMap()
+	if cm == nil {
+		return fmt.Errorf("no map is open")
+	}
+	q, err := parseQuery(s.query)
+	if err != nil {
+		return err
+	}
+	sm := cm.Get()
+	matches := SelectedCoords{}
+	matches.Clear()
+	t := &queryTile{dm: m.context.DataManager()}
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				t.y, t.x, t.z, t.stack = y, x, z, sm.Tiles[y][x][z]
+				if q(t) {
+					matches.Select(y, x, z)
+				}
+			}
+		}
+	}
+	switch s.mode {
+	case selectByReplace:
+		m.selectedCoords.Set(matches)
+	case selectByAdd:
+		m.selectedCoords.Add(matches)
+	case selectBySubtract:
+		m.selectedCoords.Remove(matches)
+	}
+	m.selectArchetype()
+	return nil
+}
+
+func (m *Mapset) layoutSelectByPopup() g.Widget {
+	s := &m.selectBy
+	return g.PopupModal("Select By").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.InputText(&s.query).Label("Query"),
+		g.Custom(func() {
+			for _, line := range queryHelp {
+				g.Label(line).Build()
+			}
+			for mode, name := range selectByModeNames {
+				func(mode int) {
+					g.Selectable(name).Selected(s.mode == mode).OnClick(func() {
+						s.mode = mode
+					}).Build()
+				}(mode)
+			}
+			if s.err != nil {
+				g.Label(s.err.Error()).Build()
+			}
+		}),
+		g.Row(
+			g.Button("Select").OnClick(func() {
+				if s.err = m.applySelectBy(); s.err == nil {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
@@ -0,0 +1,85 @@
+package mapview
+
+import (
+	"testing"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "the query is empty"},
+		{"   ", "the query is empty"},
+		{"x ! 1", "expected != at 3"},
+		{"arch = 'wall", "unterminated string at 8"},
+		{"x = 1 )", `unexpected ")" at 7`},
+		{"x = 1 y = 2", `unexpected "y" at 7`},
+		{"x =", "the query ends too early"},
+		{"x = 1 and", "the query ends too early"},
+		{"not", "the query ends too early"},
+		{"(x = 1", "missing ) for ( at 1"},
+		{"(x = 1 y", "missing ) for ( at 1"},
+		{"x 1 2", "expected an operator after x at 3"},
+		{"x = a", `x needs a number, not "a"`},
+		{"height < tall", `height needs a number, not "tall"`},
+		{"y ~ 1", "y can't use ~"},
+		{"colour = red", "unknown key colour"},
+		{"field.NoSuchField = 1", "archetypes have no field NoSuchField"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("parsed without an error, want %q", tt.want)
+			}
+			if q != nil {
+				t.Errorf("returned a query along with the error")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryMatches(t *testing.T) {
+	tile := &queryTile{
+		y: 2, x: 1, z: 0,
+		stack: []sdata.Archetype{{Archs: []string{"floor"}}, {Arch: "wall"}},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"x = 1", true},
+		{"x != 1", false},
+		{"y >= 2 and z < 1", true},
+		{"y > 2 or z <= 0", true},
+		{"not (x = 1 or y = 0)", false},
+		{"not x = 0 and not y = 0", true},
+		{"height = 2", true},
+		{"arch = wall", true},
+		{"arch = floor", true},
+		{"arch != door", true},
+		{"arch ~ flo", true},
+		{"arch = 'wall' AND X = 1", true},
+		{`arch = "a wall"`, false},
+		{"top = wall", true},
+		{"top = floor", false},
+		{"top ~ al", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q(tile); got != tt.want {
+				t.Errorf("matched %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
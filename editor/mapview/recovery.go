@@ -0,0 +1,199 @@
+package mapview
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+	log "github.com/sirupsen/logrus"
+)
+
+// recovery holds autosaved map states offered for recovery when the mapset is opened.
+type recovery struct {
+	maps    map[string]*sdata.Map
+	names   []string
+	labels  map[string]string // Describes how each recovered map differs from the loaded one.
+	mapName string
+	pending bool // Whether the recovery popup still needs to be opened.
+}
+
+// Autosave writes the current state of the mapset's maps to its recovery file if any have changed since the last autosave. Mapsets that have never been saved are autosaved under a generated name.
+func (m *Mapset) Autosave() error {
+	if !m.Unsaved() {
+		return nil
+	}
+	if m.autosaved == nil {
+		m.autosaved = make(map[*data.UnReMap]int)
+	}
+	changed := len(m.autosaved) != len(m.maps)
+	for _, v := range m.maps {
+		if r, ok := m.autosaved[v]; !ok || r != v.Revision() {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	maps := make(map[string]*sdata.Map)
+	revisions := make(map[*data.UnReMap]int)
+	for _, v := range m.maps {
+		maps[v.DataName()] = v.Get()
+		revisions[v] = v.Revision()
+	}
+	var err error
+	if m.filename != "" {
+		err = m.context.DataManager().WriteRecovery(m.filename, maps)
+	} else {
+		if m.untitledRecovery == "" {
+			m.untitledRecovery = data.NewUntitledRecoveryName()
+		}
+		err = m.context.DataManager().WriteUntitledRecovery(m.untitledRecovery, maps)
+	}
+	if err != nil {
+		return err
+	}
+	m.autosaved = revisions
+	return nil
+}
+
+// ClearRecovery removes the mapset's autosaved maps, including those autosaved before it had a file.
+func (m *Mapset) ClearRecovery() error {
+	if m.untitledRecovery != "" {
+		if err := m.context.DataManager().ClearUntitledRecovery(m.untitledRecovery); err != nil {
+			return err
+		}
+		m.untitledRecovery = ""
+	}
+	if m.filename == "" {
+		return nil
+	}
+	return m.context.DataManager().ClearRecovery(m.filename)
+}
+
+// OfferRecovery shows a prompt to recover the given autosaved maps in place of the ones loaded from disk.
+func (m *Mapset) OfferRecovery(maps map[string]*sdata.Map) {
+	if len(maps) == 0 {
+		return
+	}
+	r := recovery{
+		maps:    maps,
+		pending: true,
+	}
+	for name := range maps {
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	r.mapName = r.names[0]
+
+	// Comparing every tile is slow for large maps, so it is only done once here rather than every frame.
+	r.labels = make(map[string]string)
+	for _, name := range r.names {
+		label := name
+		if v := m.Map(name); v == nil {
+			label += " (new)"
+		} else if changed := countChangedTiles(v.Get(), maps[name]); changed > 0 {
+			label += fmt.Sprintf(" (%d tiles changed)", changed)
+		} else if !reflect.DeepEqual(v.Get(), maps[name]) {
+			label += " (properties changed)"
+		} else {
+			label += " (unchanged)"
+		}
+		r.labels[name] = label
+	}
+	m.recovery = r
+}
+
+// OfferUntitledRecovery shows a prompt to recover the autosaved maps of a mapset that was never saved. name is the name the maps were autosaved under.
+func (m *Mapset) OfferUntitledRecovery(name string, maps map[string]*sdata.Map) {
+	m.untitledRecovery = name
+	m.OfferRecovery(maps)
+}
+
+// countChangedTiles returns how many tiles differ between the two maps. Tiles outside of either map count as changed.
+func countChangedTiles(a, b *sdata.Map) (changed int) {
+	if a == nil || b == nil {
+		return -1
+	}
+	h, w, d := a.Height, a.Width, a.Depth
+	if b.Height > h {
+		h = b.Height
+	}
+	if b.Width > w {
+		w = b.Width
+	}
+	if b.Depth > d {
+		d = b.Depth
+	}
+	getTile := func(sm *sdata.Map, y, x, z int) ([]sdata.Archetype, bool) {
+		if y < len(sm.Tiles) && x < len(sm.Tiles[y]) && z < len(sm.Tiles[y][x]) {
+			return sm.Tiles[y][x][z], true
+		}
+		return nil, false
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for z := 0; z < d; z++ {
+				t1, ok1 := getTile(a, y, x, z)
+				t2, ok2 := getTile(b, y, x, z)
+				if ok1 != ok2 || (len(t1) != 0 || len(t2) != 0) && !reflect.DeepEqual(t1, t2) {
+					changed++
+				}
+			}
+		}
+	}
+	return
+}
+
+func (m *Mapset) layoutRecoveryPopup() g.Widget {
+	r := &m.recovery
+	return g.PopupModal("Recover Unsaved Changes").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Custom(func() {
+			if r.maps == nil {
+				g.CloseCurrentPopup()
+				return
+			}
+			var mapItems g.Layout
+			for _, name := range r.names {
+				func(name string) {
+					mapItems = append(mapItems, g.Selectable(r.labels[name]).Selected(name == r.mapName).OnClick(func() {
+						r.mapName = name
+					}))
+				}(name)
+			}
+			var diskMap *sdata.Map
+			if v := m.Map(r.mapName); v != nil {
+				diskMap = v.Get()
+			}
+
+			g.Label(fmt.Sprintf("Autosaved changes to %s are newer than the file on disk.", m.shortname)).Build()
+			g.Child().Border(true).Size(backupPreviewWidth*2, 80).Layout(mapItems).Build()
+			g.Row(
+				g.Column(
+					g.Label("On disk"),
+					m.layoutBackupPreview(diskMap),
+				),
+				g.Column(
+					g.Label("Recovered"),
+					m.layoutBackupPreview(r.maps[r.mapName]),
+				),
+			).Build()
+			g.Row(
+				g.Button("Recover").OnClick(func() {
+					m.replaceMaps(r.maps)
+					m.recovery = recovery{}
+					g.CloseCurrentPopup()
+				}),
+				g.Button("Discard").OnClick(func() {
+					if err := m.ClearRecovery(); err != nil {
+						log.Errorln(err)
+					}
+					m.recovery = recovery{}
+					g.CloseCurrentPopup()
+				}),
+			).Build()
+		}),
+	)
+}
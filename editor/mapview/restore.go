@@ -0,0 +1,186 @@
+package mapview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+const (
+	backupPreviewWidth  = 320
+	backupPreviewHeight = 240
+)
+
+// backupRestore holds the state of the restore from backup dialog.
+type backupRestore struct {
+	backups  []data.MapBackup
+	selected int
+	maps     map[string]*sdata.Map
+	names    []string
+	mapName  string
+	err      error
+}
+
+// openRestore gathers the backups of the mapset's file for the restore dialog.
+func (m *Mapset) openRestore() {
+	backups, err := m.context.DataManager().GetMapBackups(m.filename)
+	m.restore = backupRestore{
+		backups:  backups,
+		selected: -1,
+		err:      err,
+	}
+}
+
+// selectBackup loads the given backup for previewing.
+func (m *Mapset) selectBackup(index int) {
+	r := &m.restore
+	r.selected = index
+	r.maps, r.err = m.context.DataManager().LoadMap(r.backups[index].Path)
+	r.names = nil
+	for name := range r.maps {
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	if _, ok := r.maps[r.mapName]; !ok {
+		r.mapName = ""
+		if len(r.names) > 0 {
+			r.mapName = r.names[0]
+		}
+	}
+}
+
+// restoreBackup replaces the mapset's maps with those in the selected backup.
+func (m *Mapset) restoreBackup() {
+	m.replaceMaps(m.restore.maps)
+	m.restore = backupRestore{}
+}
+
+// replaceMaps replaces the mapset's maps with the given ones. Each replaced map gets an undo step, new maps are added, and maps missing from the given ones are kept.
+func (m *Mapset) replaceMaps(maps map[string]*sdata.Map) {
+	var names []string
+	for name := range maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := m.Map(name); v != nil {
+			v.Set(maps[name])
+		} else {
+			v := data.NewUnReMap(maps[name], name)
+			v.SetUnsaved(true)
+			m.maps = append(m.maps, v)
+		}
+	}
+	m.ensure()
+}
+
+// layoutBackupPreview draws a top-down preview of the topmost archetype in each column of the map.
+func (m *Mapset) layoutBackupPreview(sm *sdata.Map) g.Widget {
+	return g.Child().Border(true).Flags(g.WindowFlagsHorizontalScrollbar).Size(backupPreviewWidth, backupPreviewHeight).Layout(
+		g.Custom(func() {
+			if sm == nil || sm.Width <= 0 || sm.Depth <= 0 {
+				return
+			}
+			dm := m.context.DataManager()
+			cell := backupPreviewWidth / sm.Width
+			if cell > int(dm.AnimationsConfig.TileWidth) {
+				cell = int(dm.AnimationsConfig.TileWidth)
+			} else if cell < 2 {
+				cell = 2
+			}
+			pos := g.GetCursorScreenPos()
+			canvas := g.GetCanvas()
+			for x := 0; x < sm.Width; x++ {
+				for z := 0; z < sm.Depth; z++ {
+					for y := sm.Height - 1; y >= 0; y-- {
+						tiles := m.getTiles(sm, y, x, z)
+						if tiles == nil || len(*tiles) == 0 {
+							continue
+						}
+						anim, face := dm.GetAnimAndFace(&(*tiles)[len(*tiles)-1], "", "")
+						imageName, err := dm.GetAnimFaceImage(anim, face)
+						if err != nil {
+							break
+						}
+						if tex, ok := m.context.ImageTextures()[imageName]; ok && tex.Texture != nil {
+							p1 := pos.Add(image.Pt(x*cell, z*cell))
+							canvas.AddImageV(tex.Texture, p1, p1.Add(image.Pt(cell, cell)), image.Pt(0, 0), image.Pt(1, 1), color.RGBA{255, 255, 255, 255})
+						}
+						break
+					}
+				}
+			}
+			g.Dummy(float32(sm.Width*cell), float32(sm.Depth*cell)).Build()
+		}),
+	)
+}
+
+func (m *Mapset) layoutRestorePopup() g.Widget {
+	r := &m.restore
+	return g.PopupModal("Restore from Backup").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Custom(func() {
+			if len(r.backups) == 0 {
+				g.Label("There are no backups of this mapset.").Build()
+				if r.err != nil {
+					g.Label(r.err.Error()).Build()
+				}
+				g.Button("Close").OnClick(func() {
+					g.CloseCurrentPopup()
+				}).Build()
+				return
+			}
+
+			var backupItems g.Layout
+			for i, b := range r.backups {
+				func(i int, b data.MapBackup) {
+					backupItems = append(backupItems, g.Selectable(b.Time.Format("2006-01-02 15:04:05")).Selected(i == r.selected).OnClick(func() {
+						m.selectBackup(i)
+					}))
+				}(i, b)
+			}
+			var mapItems g.Layout
+			for _, name := range r.names {
+				func(name string) {
+					sm := r.maps[name]
+					label := fmt.Sprintf("%s(%s) %dx%dx%d", name, sm.Name, sm.Width, sm.Depth, sm.Height)
+					if m.Map(name) == nil {
+						label += " (new)"
+					}
+					mapItems = append(mapItems, g.Selectable(label).Selected(name == r.mapName).OnClick(func() {
+						r.mapName = name
+					}))
+				}(name)
+			}
+
+			g.Row(
+				g.Child().Border(true).Size(180, backupPreviewHeight).Layout(backupItems),
+				g.Column(
+					g.Child().Border(true).Size(backupPreviewWidth, 80).Layout(mapItems),
+					m.layoutBackupPreview(r.maps[r.mapName]),
+				),
+			).Build()
+			if r.err != nil {
+				g.Label(r.err.Error()).Build()
+			}
+			g.Label("Restoring replaces each map with its backup as an undoable change.").Build()
+			g.Row(
+				g.Button("Cancel").OnClick(func() {
+					m.restore = backupRestore{}
+					g.CloseCurrentPopup()
+				}),
+				g.Button("Restore").OnClick(func() {
+					if r.selected < 0 || r.err != nil {
+						return
+					}
+					m.restoreBackup()
+					g.CloseCurrentPopup()
+				}),
+			).Build()
+		}),
+	)
+}
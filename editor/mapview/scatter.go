@@ -0,0 +1,177 @@
+package mapview
+
+import (
+	"fmt"
+	"math/rand"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// scatterEntry is an archetype in the scatter palette and how likely it is to be picked.
+type scatterEntry struct {
+	arch   string
+	weight int32
+}
+
+// scatterBrush holds the settings of the scatter tool.
+type scatterBrush struct {
+	palette []scatterEntry
+	density int32 // Percent of tiles under the brush that get an archetype.
+	radius  int32
+	seed    int32
+	visited SelectedCoords // Tiles painted during the current stroke.
+}
+
+func newScatterBrush() scatterBrush {
+	b := scatterBrush{
+		density: 30,
+		radius:  2,
+		seed:    rand.Int31(),
+	}
+	b.visited.Clear()
+	return b
+}
+
+// tileRand returns a random source for the tile that depends only on the seed and the tile's coordinates, so that painting over the same tiles with the same seed always gives the same result.
+func (b *scatterBrush) tileRand(y, x, z int) *rand.Rand {
+	return rand.New(rand.NewSource(int64(b.seed) ^ int64(y)*73856093 ^ int64(x)*19349663 ^ int64(z)*83492791))
+}
+
+// pick returns a weighted random archetype from the palette.
+func (b *scatterBrush) pick(r *rand.Rand) string {
+	total := int32(0)
+	for _, e := range b.palette {
+		if e.weight > 0 {
+			total += e.weight
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	n := r.Int31n(total)
+	for _, e := range b.palette {
+		if e.weight <= 0 {
+			continue
+		}
+		if n < e.weight {
+			return e.arch
+		}
+		n -= e.weight
+	}
+	return ""
+}
+
+// isTopArch returns whether the topmost archetype of the tile is the given archetype.
+func (m *Mapset) isTopArch(sm *sdata.Map, y, x, z int, arch string) bool {
+	tiles := m.getTiles(sm, y, x, z)
+	if tiles == nil || len(*tiles) == 0 {
+		return false
+	}
+	if (*tiles)[len(*tiles)-1].Arch == arch {
+		return true
+	}
+	for _, a := range (*tiles)[len(*tiles)-1].Archs {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// scatterTile scatters onto a single tile and returns whether it was changed.
+func (m *Mapset) scatterTile(sm *sdata.Map, y, x, z int) bool {
+	b := &m.scatter
+	if m.uniqueTileVisits {
+		if b.visited.Selected(y, x, z) {
+			return false
+		}
+		b.visited.Select(y, x, z)
+	}
+	r := b.tileRand(y, x, z)
+	if r.Int31n(100) >= b.density {
+		return false
+	}
+	arch := b.pick(r)
+	if arch == "" {
+		return false
+	}
+	if m.keepSameTile && m.isTopArch(sm, y, x, z, arch) {
+		return false
+	}
+	return m.insertArchetype(sm, arch, y, x, z, -1) == nil
+}
+
+func (m *Mapset) toolScatter(state ButtonState, v *data.UnReMap, y, x, z int) (err error) {
+	b := &m.scatter
+	clone := v.Clone()
+	changed := false
+	if state == Trigger {
+		// Scatter over the whole selection.
+		for c := range m.selectedCoords.Get() {
+			if m.scatterTile(clone, c[0], c[1], c[2]) {
+				changed = true
+			}
+		}
+	} else if state == Down || state == Held {
+		radius := int(b.radius)
+		for dx := -radius; dx <= radius; dx++ {
+			for dz := -radius; dz <= radius; dz++ {
+				if dx*dx+dz*dz > radius*radius {
+					continue
+				}
+				if m.scatterTile(clone, y, x+dx, z+dz) {
+					changed = true
+				}
+			}
+		}
+	}
+	if changed {
+		v.Set(clone)
+	}
+	return
+}
+
+func (m *Mapset) layoutScatterSettings() g.Widget {
+	b := &m.scatter
+	return g.Custom(func() {
+		g.Label("Scatter").Build()
+		g.SliderInt(&b.density, 1, 100).Label("Density").Format("%d%%").Build()
+		g.SliderInt(&b.radius, 0, 10).Label("Radius").Format("%d").Build()
+		g.InputInt(&b.seed).Label("Seed").Build()
+		g.Row(
+			g.Button("Random Seed").OnClick(func() {
+				b.seed = rand.Int31()
+			}),
+			g.Button("Fill Selection").OnClick(func() {
+				if cm := m.CurrentMap(); cm != nil {
+					m.useTool(scatterTool, Trigger, cm, 0, 0, 0)
+				}
+			}),
+		).Build()
+		for i := range b.palette {
+			func(i int) {
+				e := &b.palette[i]
+				g.Row(
+					g.Button(fmt.Sprintf("x##scatter%d", i)).OnClick(func() {
+						b.palette = append(b.palette[:i], b.palette[i+1:]...)
+					}),
+					g.SliderInt(&e.weight, 0, 100).Label(fmt.Sprintf("%s##scatter%d", e.arch, i)).Size(60).Format("%d"),
+				).Build()
+			}(i)
+		}
+		g.Button("Add Selected Arch").OnClick(func() {
+			arch := m.context.SelectedArch()
+			if arch == "" {
+				return
+			}
+			for _, e := range b.palette {
+				if e.arch == arch {
+					return
+				}
+			}
+			b.palette = append(b.palette, scatterEntry{arch: arch, weight: 10})
+		}).Build()
+	})
+}
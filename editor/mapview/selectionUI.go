@@ -1,9 +1,11 @@
 package mapview
 
 import (
+	"fmt"
 	"math"
 
 	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
 	"github.com/chimera-rpg/go-editor/editor/icons"
 	sdata "github.com/chimera-rpg/go-server/data"
 )
@@ -19,12 +21,17 @@ type SelectionWidget struct {
 	replaceTopmost         bool
 	replaceOverwrite       bool
 	replaceFocused         bool
+	moveContents           bool
+	clearVacated           bool
+	moveY, moveX, moveZ    int32
+	transformErr           error
 }
 
 func (s *SelectionWidget) Reset() {
 	s.ResetResize()
 	s.ResetBorderify()
 	s.ResetReplace()
+	s.ResetTransform()
 }
 
 func (s *SelectionWidget) ResetResize() {
@@ -49,6 +56,32 @@ func (s *SelectionWidget) ResetReplace() {
 	s.replaceOverwrite = true
 }
 
+func (s *SelectionWidget) ResetTransform() {
+	s.moveContents = false
+	s.clearVacated = true
+	s.moveY, s.moveX, s.moveZ = 0, 0, 0
+	s.transformErr = nil
+}
+
+// shift moves the selection, along with its contents if Move Contents is checked.
+func (s *SelectionWidget) shift(m *Mapset, y, x, z int) {
+	if !s.moveContents {
+		m.selectedCoords.Shift(y, x, z)
+		m.selectArchetype()
+		return
+	}
+	if cm := m.CurrentMap(); cm != nil {
+		s.transformErr = m.moveSelection(cm, s.clearVacated, y, x, z)
+	}
+}
+
+// transform applies one of the content transforms to the current map.
+func (s *SelectionWidget) transform(m *Mapset, fn func(v *data.UnReMap) error) {
+	if cm := m.CurrentMap(); cm != nil {
+		s.transformErr = fn(cm)
+	}
+}
+
 func (s *SelectionWidget) Draw(m *Mapset) (l g.Layout) {
 	l = g.Layout{
 		// Move
@@ -56,39 +89,108 @@ func (s *SelectionWidget) Draw(m *Mapset) (l g.Layout) {
 		g.Child().Size(-1, 110).Border(false).Layout(
 			g.Row(
 				g.ImageButton(icons.Textures["tl"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, -1, -1)
+					s.shift(m, 0, -1, -1)
 				}),
 				g.ImageButton(icons.Textures["t"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, 0, -1)
+					s.shift(m, 0, 0, -1)
 				}),
 				g.ImageButton(icons.Textures["tr"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, 1, -1)
+					s.shift(m, 0, 1, -1)
 				}),
 				g.ImageButton(icons.Textures["u"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(1, 0, 0)
+					s.shift(m, 1, 0, 0)
 				}),
 			),
 			g.Row(
 				g.ImageButton(icons.Textures["l"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, -1, 0)
+					s.shift(m, 0, -1, 0)
 				}),
 				g.ImageButton(icons.Textures["blank"].Texture).Size(30, 30).FramePadding(0),
 				g.ImageButton(icons.Textures["r"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, 1, 0)
+					s.shift(m, 0, 1, 0)
 				}),
 			),
 			g.Row(
 				g.ImageButton(icons.Textures["bl"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, -1, 1)
+					s.shift(m, 0, -1, 1)
 				}),
 				g.ImageButton(icons.Textures["b"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, 0, 1)
+					s.shift(m, 0, 0, 1)
 				}),
 				g.ImageButton(icons.Textures["br"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(0, 1, 1)
+					s.shift(m, 0, 1, 1)
 				}),
 				g.ImageButton(icons.Textures["d"].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
-					m.selectedCoords.Shift(-1, 0, 0)
+					s.shift(m, -1, 0, 0)
+				}),
+			),
+		),
+		// Transform
+		g.Label("Transform"),
+		g.Child().Size(-1, 180).Layout(
+			g.Checkbox("Move Contents", &s.moveContents),
+			g.Tooltip("Whether the move arrows move the archetypes in the selection along with it."),
+			g.Checkbox("Clear Vacated", &s.clearVacated),
+			g.Tooltip("Whether tiles left behind by a transform are emptied."),
+			g.Row(
+				g.InputInt(&s.moveY).Size(30).Label("Y"),
+				g.InputInt(&s.moveX).Size(30).Label("X"),
+				g.InputInt(&s.moveZ).Size(30).Label("Z"),
+			),
+			g.Button("Move By").OnClick(func() {
+				s.transform(m, func(v *data.UnReMap) error {
+					return m.moveSelection(v, s.clearVacated, int(s.moveY), int(s.moveX), int(s.moveZ))
+				})
+			}),
+			g.Row(
+				g.Button("Rotate 90").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.rotateSelection(v, s.clearVacated, 1) })
+				}),
+				g.Button("180").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.rotateSelection(v, s.clearVacated, 2) })
+				}),
+				g.Button("270").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.rotateSelection(v, s.clearVacated, 3) })
+				}),
+			),
+			g.Tooltip("Rotate clockwise on the X/Z plane around the center of the selection."),
+			g.Row(
+				g.Button("Flip X").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.flipSelection(v, s.clearVacated, flipX) })
+				}),
+				g.Button("Flip Z").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.flipSelection(v, s.clearVacated, flipZ) })
+				}),
+				g.Button("Flip Y").OnClick(func() {
+					s.transform(m, func(v *data.UnReMap) error { return m.flipSelection(v, s.clearVacated, flipY) })
+				}),
+			),
+			g.Custom(func() {
+				if s.transformErr != nil {
+					g.Label(s.transformErr.Error()).Build()
+				}
+			}),
+		),
+		// Instances
+		g.Label("Instances"),
+		g.Child().Size(-1, 110).Layout(
+			g.Checkbox("Edit Instances", &m.instances.active).OnChange(func() {
+				m.selectArchetype()
+			}),
+			g.Tooltip("Edit every matching archetype in the selection at once in the archetype editor."),
+			g.InputText(&m.instances.filter).Label("Filter").OnChange(func() {
+				m.selectArchetype()
+			}),
+			g.Tooltip("Only edit archetypes whose archetype name contains this."),
+			g.Row(
+				g.Button("Use Focused").OnClick(func() {
+					m.useFocusedInstanceFilter()
+					m.selectArchetype()
+				}),
+				g.Custom(func() {
+					if m.instances.active {
+						g.Label(fmt.Sprintf("%d matching", len(m.collectInstances()))).Build()
+					}
 				}),
 			),
 		),
@@ -118,6 +220,7 @@ func (s *SelectionWidget) Draw(m *Mapset) (l g.Layout) {
 					} else if s.grow > 0 {
 						m.selectedCoords.Grow(int(s.grow), true, s.growDiagonal, s.growY, s.growX, s.growZ)
 					}
+					m.selectArchetype()
 				}),
 			),
 		),
@@ -142,6 +245,7 @@ func (s *SelectionWidget) Draw(m *Mapset) (l g.Layout) {
 				}),
 				g.Button("Apply").OnClick(func() {
 					m.selectedCoords.Border(s.outer, s.edges, s.checkY, s.checkX, s.checkZ)
+					m.selectArchetype()
 					// TODO: Grow m.selectedCoords
 				}),
 			),
@@ -177,7 +281,10 @@ func (s *SelectionWidget) Draw(m *Mapset) (l g.Layout) {
 							match = &(*focusedTiles)[m.focusedI]
 						}
 					}
-					m.replace(m.CurrentMap(), match, pos, s.replaceOverwrite)
+					m.symmetric(m.CurrentMap(), true, 0, 0, 0, func(v *data.UnReMap, y, x, z int) error {
+						m.replace(v, match, pos, s.replaceOverwrite)
+						return nil
+					})
 				}),
 			),
 		),
@@ -0,0 +1,91 @@
+package mapview
+
+import (
+	"errors"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// sliceNames names the slices along each axis, indexed like Coords.
+var sliceNames = []string{"Y Level", "X Column", "Z Row"}
+
+// newMapLike returns an empty map of the given size with the properties of sm.
+func (m *Mapset) newMapLike(sm *sdata.Map, h, w, d int) *sdata.Map {
+	newMap := m.createMap(sm.Name, sm.Description, sm.Lore, sm.Darkness, sm.ResetTime, h, w, d)
+	newMap.Script = sm.Script
+	newMap.Y = sm.Y
+	newMap.X = sm.X
+	newMap.Z = sm.Z
+	return newMap
+}
+
+// resliceMap rebuilds the current map with its size along the axis changed by grow, moving each tile to the coordinates returned by remap, or dropping it if remap returns false. The selection and cursor are remapped the same way. The change is a single undo step.
+func (m *Mapset) resliceMap(axis, grow int, remap func(c Coords) (Coords, bool)) error {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	sm := cm.Get()
+	size := Coords{sm.Height, sm.Width, sm.Depth}
+	size[axis] += grow
+	if size[axis] < 1 {
+		return errors.New("a map must keep at least one slice")
+	}
+
+	newMap := m.newMapLike(sm, size[0], size[1], size[2])
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				if to, ok := remap(Coords{y, x, z}); ok {
+					newMap.Tiles[to[0]][to[1]][to[2]] = sm.Tiles[y][x][z]
+				}
+			}
+		}
+	}
+	cm.Set(newMap)
+
+	selection := m.selectedCoords.Clone()
+	m.selectedCoords.Clear()
+	for c := range selection.Get() {
+		if to, ok := remap(c); ok {
+			m.selectedCoords.Select(to[0], to[1], to[2])
+		}
+	}
+
+	focused, ok := remap(Coords{m.focusedY, m.focusedX, m.focusedZ})
+	if !ok {
+		focused = Coords{m.focusedY, m.focusedX, m.focusedZ}
+	}
+	for i := range focused {
+		focused[i] = maxInt(minInt(focused[i], size[i]-1), 0)
+	}
+	m.moveCursor(focused[0], focused[1], focused[2], m.focusedI)
+	for _, vp := range m.viewports {
+		vp.focusedY = minInt(vp.focusedY, size[0]-1)
+	}
+	return nil
+}
+
+// insertSlice inserts an empty slice along the axis at the focused coordinate, shifting the slices from there on outwards.
+func (m *Mapset) insertSlice(axis int) error {
+	at := Coords{m.focusedY, m.focusedX, m.focusedZ}[axis]
+	return m.resliceMap(axis, 1, func(c Coords) (Coords, bool) {
+		if c[axis] >= at {
+			c[axis]++
+		}
+		return c, true
+	})
+}
+
+// deleteSlice removes the slice along the axis at the focused coordinate, shifting the slices after it inwards.
+func (m *Mapset) deleteSlice(axis int) error {
+	at := Coords{m.focusedY, m.focusedX, m.focusedZ}[axis]
+	return m.resliceMap(axis, -1, func(c Coords) (Coords, bool) {
+		if c[axis] == at {
+			return c, false
+		} else if c[axis] > at {
+			c[axis]--
+		}
+		return c, true
+	})
+}
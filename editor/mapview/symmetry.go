@@ -0,0 +1,193 @@
+package mapview
+
+import (
+	"fmt"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Symmetry modes.
+const (
+	symmetryNone    = iota
+	symmetryX       // Mirror across the X axis.
+	symmetryZ       // Mirror across the Z axis.
+	symmetryXZ      // Mirror across both axes.
+	symmetryRotate4 // Rotate a quarter turn at a time around where the axes cross.
+)
+
+var symmetryModeNames = []string{"None", "Mirror X", "Mirror Z", "Mirror X and Z", "Rotate 4-way"}
+
+// symmetry mirrors painting across axes on the X/Z plane. The axes are stored doubled so that they can sit on a tile's center (odd) or between two tiles (even).
+type symmetry struct {
+	mode         int
+	axisX, axisZ int
+	placing      bool // Whether the next click on the map places the axes.
+}
+
+// center places the axes in the middle of the map.
+func (s *symmetry) center(sm *sdata.Map) {
+	s.axisX, s.axisZ = sm.Width, sm.Depth
+}
+
+// points returns the given coordinate followed by its distinct symmetric counterparts that are within the map.
+func (s *symmetry) points(y, x, z int, sm *sdata.Map) (points [][3]int) {
+	add := func(x, z int) {
+		if x < 0 || z < 0 || x >= sm.Width || z >= sm.Depth {
+			return
+		}
+		for _, p := range points {
+			if p[1] == x && p[2] == z {
+				return
+			}
+		}
+		points = append(points, [3]int{y, x, z})
+	}
+	mirrorX, mirrorZ := s.axisX-x-1, s.axisZ-z-1
+
+	add(x, z)
+	switch s.mode {
+	case symmetryX:
+		add(mirrorX, z)
+	case symmetryZ:
+		add(x, mirrorZ)
+	case symmetryXZ:
+		add(mirrorX, z)
+		add(x, mirrorZ)
+		add(mirrorX, mirrorZ)
+	case symmetryRotate4:
+		// Rotate the doubled offset from the center. Quarter turns only land on tiles if both axes are on tile centers or both are between tiles.
+		dx, dz := 2*x+1-s.axisX, 2*z+1-s.axisZ
+		for i := 0; i < 3; i++ {
+			dx, dz = -dz, dx
+			nx, nz := s.axisX+dx-1, s.axisZ+dz-1
+			if nx%2 != 0 || nz%2 != 0 {
+				continue
+			}
+			add(nx/2, nz/2)
+		}
+	}
+	return
+}
+
+// mirrors returns whether the tool paints and should be repeated symmetrically.
+func (s *symmetry) mirrors(toolIndex int) bool {
+	if s.mode == symmetryNone {
+		return false
+	}
+	switch toolIndex {
+	case insertTool, eraseTool, fillTool, scatterTool:
+		return true
+	}
+	return false
+}
+
+// usesSelection returns whether the tool works on the selection rather than the clicked tile in the given state.
+func (m *Mapset) usesSelection(toolIndex int, state ButtonState) bool {
+	switch toolIndex {
+	case fillTool:
+		return state == Trigger || !m.bucketFill
+	case eraseTool, scatterTool:
+		return state == Trigger
+	}
+	return false
+}
+
+// symmetric calls fn for the coordinate and each of its symmetric counterparts, or once with the selection mirrored if onSelection is set. Every change is collected into a single undo step on v.
+func (m *Mapset) symmetric(v *data.UnReMap, onSelection bool, y, x, z int, fn func(v *data.UnReMap, y, x, z int) error) error {
+	if m.symmetry.mode == symmetryNone {
+		return fn(v, y, x, z)
+	}
+	sm := v.Get()
+	scratch := data.NewUnReMap(v.Clone(), v.DataName())
+	revision := scratch.Revision()
+
+	if onSelection {
+		selected := m.selectedCoords.Clone()
+		for c := range selected.Get() {
+			for _, p := range m.symmetry.points(c[0], c[1], c[2], sm) {
+				m.selectedCoords.Select(p[0], p[1], p[2])
+			}
+		}
+		err := fn(scratch, y, x, z)
+		m.selectedCoords.Set(selected)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, p := range m.symmetry.points(y, x, z, sm) {
+			if err := fn(scratch, p[0], p[1], p[2]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if scratch.Revision() != revision {
+		v.Set(scratch.Get())
+	}
+	return nil
+}
+
+// describeAxis formats a doubled axis position in tiles.
+func describeAxis(axis int) string {
+	if axis%2 == 0 {
+		return fmt.Sprintf("%d", axis/2)
+	}
+	return fmt.Sprintf("%d.5", axis/2)
+}
+
+func (m *Mapset) layoutSymmetrySettings() g.Widget {
+	s := &m.symmetry
+	return g.Custom(func() {
+		g.Label("Symmetry").Build()
+		for mode, name := range symmetryModeNames {
+			func(mode int) {
+				g.Selectable(name).Selected(s.mode == mode).OnClick(func() {
+					if s.mode == symmetryNone {
+						if cm := m.CurrentMap(); cm != nil {
+							s.center(cm.Get())
+						}
+					}
+					s.mode = mode
+				}).Build()
+			}(mode)
+		}
+		if s.mode == symmetryNone {
+			return
+		}
+		axis := func(label string, value *int) {
+			g.Row(
+				g.Button("<##"+label).OnClick(func() {
+					if *value > 0 {
+						*value--
+					}
+				}),
+				g.Button(">##"+label).OnClick(func() {
+					*value++
+				}),
+				g.Label(fmt.Sprintf("%s: %s", label, describeAxis(*value))),
+			).Build()
+		}
+		if s.mode != symmetryZ {
+			axis("X Axis", &s.axisX)
+		}
+		if s.mode != symmetryX {
+			axis("Z Axis", &s.axisZ)
+		}
+		g.Row(
+			g.Button("Place").OnClick(func() {
+				s.placing = true
+			}),
+			g.Tooltip("Click a tile to center the axes on it"),
+			g.Button("Center").OnClick(func() {
+				if cm := m.CurrentMap(); cm != nil {
+					s.center(cm.Get())
+				}
+			}),
+		).Build()
+		if s.placing {
+			g.Label("Click a tile...").Build()
+		}
+	})
+}
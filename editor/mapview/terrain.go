@@ -0,0 +1,392 @@
+package mapview
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"os"
+
+	g "github.com/AllenDang/giu"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// terrainBand is the archetype used for columns whose surface is up to a percentage of the terrain's height range.
+type terrainBand struct {
+	name  string
+	arch  string
+	upTo  int32 // Percent of the height range.
+	color color.RGBA
+}
+
+// terrainParams are the settings that shape the heightmap. A change to any of them regenerates the preview.
+type terrainParams struct {
+	useImage   bool // Read heights from a grayscale PNG instead of noise.
+	seed       int32
+	scale      int32 // Noise: width of a hill in tiles.
+	octaves    int32 // Noise: layers of finer detail.
+	imagePath  string
+	minHeight  int32
+	maxHeight  int32
+	mapW, mapD int
+}
+
+// terrain holds the state of the terrain dialog.
+type terrain struct {
+	params        terrainParams
+	previewParams *terrainParams
+	bands         []terrainBand
+	subsurface    string // Archetype below each column's surface. The column's band is used if empty.
+	clear         bool
+	heights       [][]int // Y height of each X then Z column.
+	err           error
+}
+
+func newTerrain() terrain {
+	return terrain{
+		params: terrainParams{
+			seed:      rand.Int31(),
+			scale:     16,
+			octaves:   4,
+			minHeight: 0,
+			maxHeight: 8,
+		},
+		bands: []terrainBand{
+			{name: "Water", upTo: 20, color: color.RGBA{32, 64, 192, 255}},
+			{name: "Sand", upTo: 30, color: color.RGBA{208, 192, 128, 255}},
+			{name: "Grass", upTo: 75, color: color.RGBA{64, 160, 48, 255}},
+			{name: "Rock", upTo: 100, color: color.RGBA{128, 128, 128, 255}},
+		},
+		clear: true,
+	}
+}
+
+// perlin is 2D Perlin gradient noise.
+type perlin struct {
+	perm [512]int
+}
+
+func newPerlin(seed int64) *perlin {
+	p := &perlin{}
+	r := rand.New(rand.NewSource(seed))
+	for i, v := range r.Perm(256) {
+		p.perm[i] = v
+		p.perm[i+256] = v
+	}
+	return p
+}
+
+func perlinFade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func perlinGrad(hash int, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func perlinLerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// noise returns the noise at the given point, roughly within -1 to 1.
+func (p *perlin) noise(x, y float64) float64 {
+	xi, yi := int(math.Floor(x))&255, int(math.Floor(y))&255
+	xf, yf := x-math.Floor(x), y-math.Floor(y)
+	u, v := perlinFade(xf), perlinFade(yf)
+	aa, ab := p.perm[p.perm[xi]+yi], p.perm[p.perm[xi]+yi+1]
+	ba, bb := p.perm[p.perm[xi+1]+yi], p.perm[p.perm[xi+1]+yi+1]
+	return perlinLerp(v,
+		perlinLerp(u, perlinGrad(aa, xf, yf), perlinGrad(ba, xf-1, yf)),
+		perlinLerp(u, perlinGrad(ab, xf, yf-1), perlinGrad(bb, xf-1, yf-1)),
+	)
+}
+
+// fractal returns layered noise at the given point, within 0 to 1.
+func (p *perlin) fractal(x, y float64, octaves int) float64 {
+	total, amplitude, frequency, max := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		total += p.noise(x*frequency, y*frequency) * amplitude
+		max += amplitude
+		amplitude /= 2
+		frequency *= 2
+	}
+	if max == 0 {
+		return 0.5
+	}
+	return math.Max(0, math.Min(1, (total/max+1)/2))
+}
+
+// generate recomputes the heights if the parameters have changed.
+func (t *terrain) generate() {
+	if t.previewParams != nil && *t.previewParams == t.params {
+		return
+	}
+	p := t.params
+	t.previewParams = &p
+	t.err = nil
+	t.heights = nil
+
+	var sample func(x, z int) float64
+	if !p.useImage {
+		noise := newPerlin(int64(p.seed))
+		scale := float64(p.scale)
+		if scale < 1 {
+			scale = 1
+		}
+		sample = func(x, z int) float64 {
+			return noise.fractal(float64(x)/scale, float64(z)/scale, int(p.octaves))
+		}
+	} else {
+		f, err := os.Open(p.imagePath)
+		if err != nil {
+			t.err = err
+			return
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.err = err
+			return
+		}
+		b := img.Bounds()
+		// Stretch the image over the map.
+		sample = func(x, z int) float64 {
+			ix := b.Min.X + x*b.Dx()/p.mapW
+			iy := b.Min.Y + z*b.Dy()/p.mapD
+			return float64(color.Gray16Model.Convert(img.At(ix, iy)).(color.Gray16).Y) / 0xffff
+		}
+	}
+
+	t.heights = make([][]int, p.mapW)
+	for x := range t.heights {
+		t.heights[x] = make([]int, p.mapD)
+		for z := range t.heights[x] {
+			t.heights[x][z] = int(p.minHeight) + int(math.Round(sample(x, z)*float64(p.maxHeight-p.minHeight)))
+		}
+	}
+}
+
+// getBand returns the band of a column whose surface is at the given Y level.
+func (t *terrain) getBand(y int) *terrainBand {
+	if len(t.bands) == 0 {
+		return nil
+	}
+	percent := int32(100)
+	if t.params.maxHeight > t.params.minHeight {
+		percent = int32((y - int(t.params.minHeight)) * 100 / int(t.params.maxHeight-t.params.minHeight))
+	}
+	for i := range t.bands {
+		if percent <= t.bands[i].upTo {
+			return &t.bands[i]
+		}
+	}
+	return &t.bands[len(t.bands)-1]
+}
+
+// openTerrain prepares the terrain dialog for the current map.
+func (m *Mapset) openTerrain() {
+	if cm := m.CurrentMap(); cm != nil {
+		m.terrain.params.mapW, m.terrain.params.mapD = cm.Get().Width, cm.Get().Depth
+	}
+	m.terrain.previewParams = nil
+	m.terrain.err = nil
+}
+
+// applyTerrain fills the selected columns, or every column if nothing is selected, up to their heights as a single undoable change. The map grows taller if needed.
+func (m *Mapset) applyTerrain() error {
+	t := &m.terrain
+	cm := m.CurrentMap()
+	if cm == nil {
+		return errors.New("no map is open")
+	}
+	t.generate()
+	if t.err != nil {
+		return t.err
+	}
+	if t.params.minHeight > t.params.maxHeight {
+		return errors.New("the minimum height is above the maximum height")
+	}
+	clone := cm.Clone()
+
+	// Grow the map to fit the tallest column.
+	resized := clone.Height <= int(t.params.maxHeight)
+	for clone.Height <= int(t.params.maxHeight) {
+		level := make([][][]sdata.Archetype, clone.Width)
+		for x := range level {
+			level[x] = make([][]sdata.Archetype, clone.Depth)
+			for z := range level[x] {
+				level[x][z] = []sdata.Archetype{}
+			}
+		}
+		clone.Tiles = append(clone.Tiles, level)
+		clone.Height++
+	}
+
+	columns := make(map[[2]int]struct{})
+	if m.selectedCoords.Empty() {
+		for x := 0; x < clone.Width; x++ {
+			for z := 0; z < clone.Depth; z++ {
+				columns[[2]int{x, z}] = struct{}{}
+			}
+		}
+	} else {
+		for c := range m.selectedCoords.Get() {
+			columns[[2]int{c[1], c[2]}] = struct{}{}
+		}
+	}
+
+	for c := range columns {
+		x, z := c[0], c[1]
+		if x >= len(t.heights) || z >= len(t.heights[x]) {
+			continue
+		}
+		if t.clear {
+			for y := 0; y < clone.Height; y++ {
+				if tiles := m.getTiles(clone, y, x, z); tiles != nil {
+					*tiles = []sdata.Archetype{}
+				}
+			}
+		}
+		band := t.getBand(t.heights[x][z])
+		if band == nil {
+			continue
+		}
+		for y := 0; y <= t.heights[x][z]; y++ {
+			arch := band.arch
+			if y < t.heights[x][z] && t.subsurface != "" {
+				arch = t.subsurface
+			}
+			if arch == "" {
+				continue
+			}
+			if err := m.insertArchetype(clone, arch, y, x, z, -1); err != nil {
+				return err
+			}
+		}
+	}
+	cm.Set(clone)
+	// Only a resize needs the cursor and selection reset, otherwise keep the selection for another pass.
+	if resized {
+		m.ensure()
+	} else {
+		m.selectArchetype()
+	}
+	return nil
+}
+
+func (m *Mapset) layoutTerrainPreview() g.Widget {
+	t := &m.terrain
+	return g.Custom(func() {
+		t.generate()
+		if len(t.heights) == 0 || len(t.heights[0]) == 0 {
+			return
+		}
+		cell := generatePreviewSize / maxInt(len(t.heights), len(t.heights[0]))
+		if cell < 1 {
+			cell = 1
+		}
+		pos := g.GetCursorScreenPos()
+		canvas := g.GetCanvas()
+		for x := range t.heights {
+			for z := range t.heights[x] {
+				c := color.RGBA{0, 0, 0, 255}
+				if band := t.getBand(t.heights[x][z]); band != nil {
+					c = band.color
+				}
+				// Shade by height so slopes within a band stay visible.
+				shade := 0.5
+				if t.params.maxHeight > t.params.minHeight {
+					shade += 0.5 * float64(t.heights[x][z]-int(t.params.minHeight)) / float64(t.params.maxHeight-t.params.minHeight)
+				}
+				c.R, c.G, c.B = uint8(float64(c.R)*shade), uint8(float64(c.G)*shade), uint8(float64(c.B)*shade)
+				p := pos.Add(image.Pt(x*cell, z*cell))
+				canvas.AddRectFilled(p, p.Add(image.Pt(cell, cell)), c, 0, 0)
+			}
+		}
+		g.Dummy(float32(len(t.heights)*cell), float32(len(t.heights[0])*cell)).Build()
+	})
+}
+
+func (m *Mapset) layoutTerrainPopup() g.Widget {
+	t := &m.terrain
+	return g.PopupModal("Terrain").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.Custom(func() {
+			g.Checkbox("Use Grayscale PNG", &t.params.useImage).Build()
+			g.Tooltip("Read the heights from an image stretched over the map instead of generating noise").Build()
+			if !t.params.useImage {
+				g.Row(
+					g.InputInt(&t.params.seed).Label("Seed"),
+					g.Button("Random").OnClick(func() {
+						t.params.seed = rand.Int31()
+					}),
+				).Build()
+				g.SliderInt(&t.params.scale, 1, 128).Label("Scale").Format("%d").Build()
+				g.SliderInt(&t.params.octaves, 1, 8).Label("Detail").Format("%d").Build()
+			} else {
+				g.InputText(&t.params.imagePath).Label("Image").Build()
+			}
+			g.SliderInt(&t.params.minHeight, 0, 64).Label("Min Height").Format("%d").Build()
+			g.SliderInt(&t.params.maxHeight, 0, 64).Label("Max Height").Format("%d").Build()
+			for i := range t.bands {
+				func(band *terrainBand) {
+					g.Row(
+						g.InputText(&band.arch).Label(band.name),
+						g.Button("Use Selected##"+band.name).OnClick(func() {
+							band.arch = m.context.SelectedArch()
+						}),
+						g.SliderInt(&band.upTo, 0, 100).Label("##upTo"+band.name).Format("up to %d%%"),
+					).Build()
+				}(&t.bands[i])
+			}
+			g.Row(
+				g.InputText(&t.subsurface).Label("Subsurface"),
+				g.Button("Use Selected##subsurface").OnClick(func() {
+					t.subsurface = m.context.SelectedArch()
+				}),
+			).Build()
+			g.Tooltip("Fills each column below its surface. Leave empty to fill the whole column with its band").Build()
+			g.Checkbox("Clear Columns", &t.clear).Build()
+			g.Tooltip("Remove everything in each column before filling it").Build()
+			if m.selectedCoords.Empty() {
+				g.Label("Fills the whole map").Build()
+			} else {
+				g.Label("Fills the selected columns").Build()
+			}
+		}),
+		m.layoutTerrainPreview(),
+		g.Custom(func() {
+			if t.err != nil {
+				g.Label(t.err.Error()).Build()
+			}
+		}),
+		g.Row(
+			g.Button("Apply").OnClick(func() {
+				if t.err = m.applyTerrain(); t.err == nil {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
@@ -0,0 +1,129 @@
+package mapview
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	g "github.com/AllenDang/giu"
+	"github.com/chimera-rpg/go-editor/data"
+)
+
+// tiledTransfer holds the state of the Tiled import and export dialogs.
+type tiledTransfer struct {
+	importPath, mappingPath, dataName string
+	stack                             bool
+	exportPath                        string
+	err                               error
+	warnings                          []string
+}
+
+// openTiledImport resets the Tiled import dialog.
+func (m *Mapset) openTiledImport() {
+	m.tiled.err = nil
+	m.tiled.warnings = nil
+}
+
+// openTiledExport resets the Tiled export dialog, suggesting a file next to the mapset named after the current map.
+func (m *Mapset) openTiledExport() {
+	m.tiled.err = nil
+	m.tiled.warnings = nil
+	dir := m.context.DataManager().MapsPath
+	if m.filename != "" {
+		dir = filepath.Dir(m.filename)
+	}
+	if cm := m.CurrentMap(); cm != nil {
+		m.tiled.exportPath = filepath.Join(dir, strings.ReplaceAll(cm.DataName(), "/", "_")+".tiled.json")
+	}
+}
+
+// importTiled adds the Tiled map as a new map in the mapset. It returns whether the import succeeded.
+func (m *Mapset) importTiled() bool {
+	t := &m.tiled
+	var opts data.TiledImportOptions
+	opts.Stack = t.stack
+	if t.mappingPath != "" {
+		if opts.Mapping, t.err = data.LoadTiledMapping(t.mappingPath); t.err != nil {
+			return false
+		}
+	}
+	dataName := t.dataName
+	if dataName == "" {
+		dataName = strings.TrimSuffix(filepath.Base(t.importPath), filepath.Ext(t.importPath))
+	}
+	if m.Map(dataName) != nil {
+		t.err = fmt.Errorf("a map named %s already exists", dataName)
+		return false
+	}
+	sm, warnings, err := m.context.DataManager().ImportTiled(t.importPath, opts)
+	if err != nil {
+		t.err = err
+		return false
+	}
+	t.warnings = warnings
+	m.SetMap(dataName, sm)
+	m.SelectMap(dataName)
+	return true
+}
+
+// exportTiled writes the current map as a Tiled JSON map. It returns whether the export succeeded.
+func (m *Mapset) exportTiled() bool {
+	cm := m.CurrentMap()
+	if cm == nil {
+		return false
+	}
+	m.tiled.err = m.context.DataManager().ExportTiled(m.tiled.exportPath, cm.Get())
+	return m.tiled.err == nil
+}
+
+// layoutTiledStatus shows the last error or the warnings from the last import.
+func (m *Mapset) layoutTiledStatus() g.Widget {
+	return g.Custom(func() {
+		if m.tiled.err != nil {
+			g.Label(m.tiled.err.Error()).Build()
+		}
+		for _, w := range m.tiled.warnings {
+			g.Label(w).Build()
+		}
+	})
+}
+
+func (m *Mapset) layoutTiledImportPopup() g.Widget {
+	t := &m.tiled
+	return g.PopupModal("Import from Tiled").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.InputText(&t.importPath).Label("Tiled Map (.tmx, .json)"),
+		g.InputText(&t.mappingPath).Label("Mapping File (optional)"),
+		g.InputText(&t.dataName).Label("Data Name"),
+		g.Checkbox("Stack Layers", &t.stack),
+		g.Tooltip("Place every layer on the same Y level instead of one Y level per layer"),
+		m.layoutTiledStatus(),
+		g.Row(
+			g.Button("Import").OnClick(func() {
+				if m.importTiled() && len(t.warnings) == 0 {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Close").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
+
+func (m *Mapset) layoutTiledExportPopup() g.Widget {
+	t := &m.tiled
+	return g.PopupModal("Export to Tiled").Flags(g.WindowFlagsAlwaysAutoResize).Layout(
+		g.InputText(&t.exportPath).Label("Tiled Map (.json)"),
+		m.layoutTiledStatus(),
+		g.Row(
+			g.Button("Export").OnClick(func() {
+				if m.exportTiled() {
+					g.CloseCurrentPopup()
+				}
+			}),
+			g.Button("Cancel").OnClick(func() {
+				g.CloseCurrentPopup()
+			}),
+		),
+	)
+}
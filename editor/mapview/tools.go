@@ -30,6 +30,9 @@ const (
 	pickTool
 	eraseTool
 	fillTool
+	pathTool
+	scatterTool
+	moveTool
 )
 
 func (m *Mapset) bindMouseToTool(btn g.MouseButton, toolIndex int) {
@@ -83,6 +86,16 @@ func (m *Mapset) handleMouseTool(btn g.MouseButton, state ButtonState, y, x, z i
 		}
 		cm := m.maps[m.currentMapIndex]
 
+		// Swallow the click that places the symmetry axes.
+		if m.symmetry.placing {
+			if state == Down {
+				m.symmetry.axisX, m.symmetry.axisZ = x*2+1, z*2+1
+			} else if state == Up {
+				m.symmetry.placing = false
+			}
+			return nil
+		}
+
 		if m.uniqueTileVisits {
 			if state == Down || state == Held {
 				if m.visitedCoords.Selected(y, x, z) {
@@ -95,23 +108,55 @@ func (m *Mapset) handleMouseTool(btn g.MouseButton, state ButtonState, y, x, z i
 			}
 		}
 
-		if toolIndex == insertTool {
-			return m.toolInsert(state, cm, y, x, z)
-		} else if toolIndex == selectTool {
-			return m.toolSelect(state, selectTool, cm, y, x, z)
-		} else if toolIndex == cselectTool {
-			return m.toolSelect(state, cselectTool, cm, y, x, z)
-		} else if toolIndex == lselectTool {
-			return m.toolSelect(state, lselectTool, cm, y, x, z)
-		} else if toolIndex == wandTool {
-			return m.toolSelect(state, wandTool, cm, y, x, z)
-		} else if toolIndex == eraseTool {
-			return m.toolErase(state, cm, y, x, z)
-		} else if toolIndex == fillTool {
-			return m.toolFill(state, cm, y, x, z)
-		} else if toolIndex == pickTool {
-			return m.toolPick(state, cm, y, x, z)
-		}
+		return m.useTool(toolIndex, state, cm, y, x, z)
+	}
+	return nil
+}
+
+// useTool runs the tool, repeating it symmetrically if it paints.
+func (m *Mapset) useTool(toolIndex int, state ButtonState, v *data.UnReMap, y, x, z int) (err error) {
+	revision := v.Revision()
+	// Scatter remembers the tiles it has visited for a whole stroke, including every mirrored point.
+	if toolIndex == scatterTool && (state == Down || state == Trigger) {
+		m.scatter.visited.Clear()
+	}
+	if !m.symmetry.mirrors(toolIndex) {
+		err = m.runTool(toolIndex, state, v, y, x, z)
+	} else {
+		err = m.symmetric(v, m.usesSelection(toolIndex, state), y, x, z, func(v *data.UnReMap, y, x, z int) error {
+			return m.runTool(toolIndex, state, v, y, x, z)
+		})
+	}
+	// Point the archetype editor at the new map state.
+	if v.Revision() != revision {
+		m.selectArchetype()
+	}
+	return
+}
+
+func (m *Mapset) runTool(toolIndex int, state ButtonState, v *data.UnReMap, y, x, z int) error {
+	if toolIndex == insertTool {
+		return m.toolInsert(state, v, y, x, z)
+	} else if toolIndex == selectTool {
+		return m.toolSelect(state, selectTool, v, y, x, z)
+	} else if toolIndex == cselectTool {
+		return m.toolSelect(state, cselectTool, v, y, x, z)
+	} else if toolIndex == lselectTool {
+		return m.toolSelect(state, lselectTool, v, y, x, z)
+	} else if toolIndex == wandTool {
+		return m.toolSelect(state, wandTool, v, y, x, z)
+	} else if toolIndex == eraseTool {
+		return m.toolErase(state, v, y, x, z)
+	} else if toolIndex == fillTool {
+		return m.toolFill(state, v, y, x, z)
+	} else if toolIndex == pickTool {
+		return m.toolPick(state, v, y, x, z)
+	} else if toolIndex == pathTool {
+		return m.toolPath(state, v, y, x, z)
+	} else if toolIndex == scatterTool {
+		return m.toolScatter(state, v, y, x, z)
+	} else if toolIndex == moveTool {
+		return m.toolMove(state, v, y, x, z)
 	}
 	return nil
 }
@@ -145,13 +190,18 @@ func (m *Mapset) toolSelect(state ButtonState, subTool int, v *data.UnReMap, y,
 		m.selectingYEnd = y
 		m.selectingXEnd = x
 		m.selectingZEnd = z
-		m.selectingCoords.Clear()
+		if subTool != wandTool {
+			m.selectingCoords.Clear()
+		}
 		if subTool == cselectTool {
 			m.selectingCoords.RangeCircle(true, m.selectingYStart, m.selectingXStart, m.selectingZStart, m.selectingYEnd, m.selectingXEnd, m.selectingZEnd)
 		} else if subTool == lselectTool {
 			m.selectingCoords.Line(true, m.selectingYStart, m.selectingXStart, m.selectingZStart, m.selectingYEnd, m.selectingXEnd, m.selectingZEnd)
 		} else if subTool == wandTool {
-			// TODO
+			// Dragging adds the region under each tile passed over.
+			if m.selectingCoords.Unselected(y, x, z) {
+				m.selectingCoords.FloodSelect(true, y, x, z, m)
+			}
 		} else {
 			m.selectingCoords.Range(true, m.selectingYStart, m.selectingXStart, m.selectingZStart, m.selectingYEnd, m.selectingXEnd, m.selectingZEnd)
 		}
@@ -198,18 +248,8 @@ func (m *Mapset) toolInsert(state ButtonState, v *data.UnReMap, y, x, z int) (er
 		return
 	}
 	// Check if we should not insert if top tile is the same.
-	if m.keepSameTile {
-		tiles := m.getTiles(v.Get(), y, x, z)
-		if tiles != nil && len(*tiles) > 0 {
-			if (*tiles)[len(*tiles)-1].Arch == m.context.SelectedArch() {
-				return
-			}
-			for _, a := range (*tiles)[len(*tiles)-1].Archs {
-				if a == m.context.SelectedArch() {
-					return
-				}
-			}
-		}
+	if m.keepSameTile && m.isTopArch(v.Get(), y, x, z, m.context.SelectedArch()) {
+		return
 	}
 	// Otherwise attempt to insert.
 	clone := v.Clone()
@@ -310,9 +350,13 @@ func (m *Mapset) toolFill(state ButtonState, v *data.UnReMap, y, x, z int) (err
 		return
 	}
 	if state == Trigger || state == Up {
+		coords := m.selectedCoords.Get()
+		if m.bucketFill && state == Up {
+			coords = m.floodCoords(v.Get(), m.flood, y, x, z)
+		}
 		clone := v.Clone()
 		changed := false
-		for coord := range m.selectedCoords.Get() {
+		for coord := range coords {
 			y, x, z := coord[0], coord[1], coord[2]
 
 			place := true
@@ -368,6 +412,13 @@ func (m *Mapset) toolPick(state ButtonState, v *data.UnReMap, y, x, z int) (err
 	return
 }
 
+func (m *Mapset) toolPath(state ButtonState, v *data.UnReMap, y, x, z int) (err error) {
+	if state == Trigger || state == Up {
+		m.setPathPoint(v, y, x, z)
+	}
+	return
+}
+
 func (m *Mapset) replace(v *data.UnReMap, match *sdata.Archetype, pos int, overwrite bool) {
 	clone := v.Clone()
 	changed := false
@@ -0,0 +1,130 @@
+package mapview
+
+import (
+	"errors"
+	"math"
+
+	"github.com/chimera-rpg/go-editor/data"
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// Axes to flip the selection on, as indexes into Coords.
+const (
+	flipY = iota
+	flipX
+	flipZ
+)
+
+// selectionBounds returns the smallest and largest selected coordinates.
+func (m *Mapset) selectionBounds() (min, max Coords) {
+	min = Coords{math.MaxInt32, math.MaxInt32, math.MaxInt32}
+	max = Coords{math.MinInt32, math.MinInt32, math.MinInt32}
+	for c := range m.selectedCoords.Get() {
+		for i := range c {
+			if c[i] < min[i] {
+				min[i] = c[i]
+			}
+			if c[i] > max[i] {
+				max[i] = c[i]
+			}
+		}
+	}
+	return
+}
+
+// transformSelection moves the contents of the selected tiles to the coordinates returned by transform as a single undo step. If clear is set the tiles left behind are emptied, otherwise they keep their contents. The selection and cursor follow the contents.
+func (m *Mapset) transformSelection(v *data.UnReMap, clear bool, transform func(c Coords) Coords) error {
+	if m.selectedCoords.Empty() {
+		return errors.New("nothing is selected")
+	}
+	sm := v.Get()
+	moved := make(map[Coords][]sdata.Archetype)
+	for c := range m.selectedCoords.Get() {
+		tiles := m.getTiles(sm, c[0], c[1], c[2])
+		if tiles == nil {
+			continue
+		}
+		to := transform(c)
+		if m.getTiles(sm, to[0], to[1], to[2]) == nil {
+			return errors.New("the selection would leave the map")
+		}
+		moved[to] = append([]sdata.Archetype{}, (*tiles)...)
+	}
+
+	clone := v.Clone()
+	if clear {
+		for c := range m.selectedCoords.Get() {
+			if tiles := m.getTiles(clone, c[0], c[1], c[2]); tiles != nil {
+				*tiles = []sdata.Archetype{}
+			}
+		}
+	}
+	for to, archs := range moved {
+		*m.getTiles(clone, to[0], to[1], to[2]) = archs
+	}
+	v.Set(clone)
+
+	selection := m.selectedCoords.Clone()
+	focused := m.selectedCoords.Selected(m.focusedY, m.focusedX, m.focusedZ)
+	m.selectedCoords.Clear()
+	for c := range selection.Get() {
+		to := transform(c)
+		m.selectedCoords.Select(to[0], to[1], to[2])
+	}
+	if focused {
+		to := transform(Coords{m.focusedY, m.focusedX, m.focusedZ})
+		m.moveCursor(to[0], to[1], to[2], m.focusedI)
+	} else {
+		m.selectArchetype()
+	}
+	return nil
+}
+
+// moveSelection moves the contents of the selection by the given offset.
+func (m *Mapset) moveSelection(v *data.UnReMap, clear bool, y, x, z int) error {
+	return m.transformSelection(v, clear, func(c Coords) Coords {
+		return Coords{c[0] + y, c[1] + x, c[2] + z}
+	})
+}
+
+// rotateSelection rotates the contents of the selection clockwise on the X/Z plane by the given number of quarter turns around the center of the selection.
+func (m *Mapset) rotateSelection(v *data.UnReMap, clear bool, turns int) error {
+	min, max := m.selectionBounds()
+	// Work with doubled offsets so the center can fall between tiles.
+	cx, cz := min[1]+max[1], min[2]+max[2]
+	turns = (turns%4 + 4) % 4
+	return m.transformSelection(v, clear, func(c Coords) Coords {
+		dx, dz := 2*c[1]-cx, 2*c[2]-cz
+		for i := 0; i < turns; i++ {
+			dx, dz = -dz, dx
+		}
+		// Round down consistently if the selection's width and depth differ in parity.
+		return Coords{c[0], (cx + dx) >> 1, (cz + dz) >> 1}
+	})
+}
+
+// flipSelection mirrors the contents of the selection within its bounds along the given axis.
+func (m *Mapset) flipSelection(v *data.UnReMap, clear bool, axis int) error {
+	min, max := m.selectionBounds()
+	return m.transformSelection(v, clear, func(c Coords) Coords {
+		c[axis] = min[axis] + max[axis] - c[axis]
+		return c
+	})
+}
+
+// toolMove drags the contents of the selection. The moved selection is previewed while dragging.
+func (m *Mapset) toolMove(state ButtonState, v *data.UnReMap, y, x, z int) (err error) {
+	if state == Down {
+		m.moveStart = Coords{y, x, z}
+		m.selectingCoords.Clear()
+	} else if state == Held {
+		m.selectingCoords.Set(m.selectedCoords)
+		m.selectingCoords.Shift(y-m.moveStart[0], x-m.moveStart[1], z-m.moveStart[2])
+	} else if state == Up {
+		m.selectingCoords.Clear()
+		if y != m.moveStart[0] || x != m.moveStart[1] || z != m.moveStart[2] {
+			err = m.moveSelection(v, m.selectionWidget.clearVacated, y-m.moveStart[0], x-m.moveStart[1], z-m.moveStart[2])
+		}
+	}
+	return
+}
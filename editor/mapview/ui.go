@@ -24,12 +24,22 @@ var selectingBackgroundColor = color.RGBA{128, 128, 128, 64}
 var hoveredBorderColor = color.RGBA{255, 255, 0, 128}
 var hoveredHeightBoxColor = color.RGBA{255, 255, 0, 64}
 var hoveredBackgroundColor = color.RGBA{255, 255, 0, 0}
+var pitchBlackColor = color.RGBA{255, 0, 64, 160}
+var blockingColor = color.RGBA{255, 0, 0, 64}
+var walkableColor = color.RGBA{0, 255, 0, 48}
+var climbableColor = color.RGBA{255, 192, 0, 64}
+var pathColor = color.RGBA{0, 192, 255, 200}
+var diffAddedColor = color.RGBA{0, 255, 0, 80}
+var diffRemovedColor = color.RGBA{255, 0, 0, 80}
+var diffChangedColor = color.RGBA{255, 255, 0, 80}
+var symmetryAxisColor = color.RGBA{255, 0, 255, 160}
 
 func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 	windowOpen := true
 
 	var mapExists bool
-	var resizeMapPopup, newMapPopup, adjustMapPopup, adjustScriptPopup, deleteMapPopup bool
+	var resizeMapPopup, newMapPopup, adjustMapPopup, adjustScriptPopup, deleteMapPopup, restoreBackupPopup bool
+	var tiledImportPopup, tiledExportPopup, generatePopup, terrainPopup, mapOpPopup, selectByPopup bool
 	var shortTitle string
 
 	if m.CurrentMap() != nil {
@@ -69,6 +79,18 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 	if m.isToolBound(eraseTool) {
 		eraseImage += "-focus"
 	}
+	pathImage := "path"
+	if m.isToolBound(pathTool) {
+		pathImage += "-focus"
+	}
+	scatterImage := "scatter"
+	if m.isToolBound(scatterTool) {
+		scatterImage += "-focus"
+	}
+	moveImage := "move"
+	if m.isToolBound(moveTool) {
+		moveImage += "-focus"
+	}
 	insertImage := "insert"
 	if m.isToolBound(insertTool) {
 		insertImage += "-focus"
@@ -120,6 +142,8 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 						newClone := m.CurrentMap().Clone()
 						m.CurrentMap().Replace(m.pendingClone)
 						m.CurrentMap().Set(newClone)
+						// Point the editor at the archetypes in the new map state.
+						m.selectArchetype()
 						return true
 					})
 					m.context.ArchEditor().SetSaveCallback(func() bool {
@@ -127,11 +151,11 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 						return true
 					})
 					m.context.ArchEditor().SetUndoCallback(func() bool {
-						m.CurrentMap().Undo()
+						m.undo()
 						return true
 					})
 					m.context.ArchEditor().SetRedoCallback(func() bool {
-						m.CurrentMap().Redo()
+						m.redo()
 						return true
 					})
 					m.selectArchetype()
@@ -146,6 +170,15 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 			}),
 			g.Separator(),
 			g.MenuItem("Save All").OnClick(func() { m.saveAll() }),
+			g.MenuItem("Restore from backup...").Enabled(m.filename != "").OnClick(func() {
+				m.openRestore()
+				restoreBackupPopup = true
+			}),
+			g.Separator(),
+			g.MenuItem("Import from Tiled...").OnClick(func() {
+				m.openTiledImport()
+				tiledImportPopup = true
+			}),
 			g.Separator(),
 			g.MenuItem("Close").OnClick(func() { m.close() }),
 		),
@@ -169,33 +202,135 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 			g.MenuItem("Resize...").Enabled(mapExists).OnClick(func() {
 				resizeMapPopup = true
 			}),
+			g.MenuItem("Crop to Selection").Enabled(mapExists && !m.selectedCoords.Empty()).OnClick(func() {
+				if err := m.cropMap(); err != nil {
+					log.Println(err)
+				}
+			}),
+			g.MenuItem("Duplicate...").Enabled(mapExists).OnClick(func() {
+				m.openMapOperation(mapOpDuplicate)
+				mapOpPopup = true
+			}),
+			g.MenuItem("Split Selection...").Enabled(mapExists && !m.selectedCoords.Empty()).OnClick(func() {
+				m.openMapOperation(mapOpSplit)
+				mapOpPopup = true
+			}),
+			g.MenuItem("Merge Map...").Enabled(mapExists && len(m.maps) > 1).OnClick(func() {
+				m.openMapOperation(mapOpMerge)
+				mapOpPopup = true
+			}),
+			g.Menu("Slices").Layout(
+				g.Custom(func() {
+					for axis, name := range sliceNames {
+						func(axis int) {
+							g.MenuItem("Insert " + name).Enabled(mapExists).OnClick(func() {
+								if err := m.insertSlice(axis); err != nil {
+									log.Println(err)
+								}
+							}).Build()
+						}(axis)
+					}
+					g.Separator().Build()
+					for axis, name := range sliceNames {
+						func(axis int) {
+							g.MenuItem("Delete " + name).Enabled(mapExists).OnClick(func() {
+								if err := m.deleteSlice(axis); err != nil {
+									log.Println(err)
+								}
+							}).Build()
+						}(axis)
+					}
+				}),
+			),
+			g.Tooltip("Insert or delete a slice at the cursor, shifting what comes after it."),
+			g.MenuItem("Export to Tiled...").Enabled(mapExists).OnClick(func() {
+				m.openTiledExport()
+				tiledExportPopup = true
+			}),
 			g.Separator(),
 			g.MenuItem("Undo").Enabled(mapExists).OnClick(func() {
-				cm := m.CurrentMap()
-				cm.Undo()
+				m.undo()
 			}),
 			g.MenuItem("Redo").Enabled(mapExists).OnClick(func() {
-				cm := m.CurrentMap()
-				cm.Redo()
+				m.redo()
 			}),
 			g.Separator(),
 			g.MenuItem("Delete...").Enabled(mapExists).OnClick(func() {
 				deleteMapPopup = true
 			}),
 		),
+		g.Menu("Select").Layout(
+			g.MenuItem("Select By...").Enabled(mapExists).OnClick(func() {
+				m.selectBy.err = nil
+				selectByPopup = true
+			}),
+		),
+		g.Menu("Generate").Layout(
+			g.Custom(func() {
+				for kind, name := range generatorNames {
+					func(kind int) {
+						g.MenuItem(name + "...").Enabled(mapExists && !m.selectedCoords.Empty()).OnClick(func() {
+							m.openGenerator(kind)
+							generatePopup = true
+						}).Build()
+					}(kind)
+				}
+				if m.selectedCoords.Empty() {
+					g.Label("Select an area to fill first").Build()
+				}
+			}),
+			g.Separator(),
+			g.MenuItem("Terrain...").Enabled(mapExists).OnClick(func() {
+				m.openTerrain()
+				terrainPopup = true
+			}),
+		),
 		g.Menu("Settings").Layout(
 			g.Checkbox("Keep Same Tile", &m.keepSameTile),
 			g.Checkbox("Only Visit Unique Tiles", &m.uniqueTileVisits),
 		),
 		g.Menu("View").Layout(
-			g.Checkbox("Z Onionskinning", &m.onionskinZ),
-			g.Checkbox("Y Onionskinning", &m.onionskinY),
-			g.Checkbox("X Onionskinning", &m.onionskinX),
-			g.SliderInt(&m.onionSkinGtIntensity, 0, 255).Label("Onionskin > Opacity").Format("%d"),
-			g.SliderInt(&m.onionSkinLtIntensity, 0, 255).Label("Onionskin < Opacity").Format("%d"),
-			g.Checkbox("Grid", &m.showGrid),
-			g.Checkbox("Y Grids", &m.showYGrids),
-			g.SliderInt(&m.zoom, 1, 8).Label("Zoom").Format("%d"),
+			g.Custom(func() {
+				viewportCount := int32(len(m.viewports))
+				g.SliderInt(&viewportCount, 1, maxViewports).Label("Viewports").Format("%d").Build()
+				if int(viewportCount) != len(m.viewports) {
+					m.setViewportCount(int(viewportCount))
+				}
+				vp := m.activeViewport()
+				g.Layout{
+					g.Separator(),
+					g.Label(fmt.Sprintf("Viewport %d", m.activeViewportIndex+1)),
+					g.Menu("Mode").Layout(
+						g.Custom(func() {
+							for mode, name := range viewModeNames {
+								func(mode int, name string) {
+									g.MenuItem(name).Selected(vp.viewMode == mode).OnClick(func() {
+										vp.setViewMode(mode)
+									}).Build()
+								}(mode, name)
+							}
+						}),
+					),
+					g.Checkbox("Z Onionskinning", &vp.onionskinZ),
+					g.Checkbox("Y Onionskinning", &vp.onionskinY),
+					g.Checkbox("X Onionskinning", &vp.onionskinX),
+					g.SliderInt(&vp.onionSkinGtIntensity, 0, 255).Label("Onionskin > Opacity").Format("%d"),
+					g.SliderInt(&vp.onionSkinLtIntensity, 0, 255).Label("Onionskin < Opacity").Format("%d"),
+					g.Checkbox("Grid", &vp.showGrid),
+					g.Checkbox("Y Grids", &vp.showYGrids),
+					g.SliderInt(&vp.zoom, 1, 8).Label("Zoom").Format("%d"),
+					g.Checkbox("Lighting Preview", &vp.showLighting),
+					g.Tooltip("Shade tiles by their light level and mark pitch-black tiles."),
+					g.Checkbox("Walkability", &vp.showWalkability),
+					g.Tooltip("Mark tiles as blocking, walkable, or climbable."),
+					g.SliderInt(&m.moverHeight, 1, 8).Label("Mover Height").Format("%d"),
+					g.Tooltip("The height of the mover used for walkability and path tests."),
+					g.SliderInt(&m.moverWidth, 1, 8).Label("Mover Width").Format("%d"),
+					g.Tooltip("The width of the mover used for walkability and path tests."),
+					g.SliderInt(&m.moverDepth, 1, 8).Label("Mover Depth").Format("%d"),
+					g.Tooltip("The depth of the mover used for walkability and path tests."),
+				}.Build()
+			}),
 		),
 	),
 		g.Row(
@@ -217,6 +352,10 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 						m.bindMouseToTool(g.MouseButtonLeft, wandTool)
 					}),
 					g.Tooltip("magic selection tool"),
+					g.ImageButton(icons.Textures[moveImage].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
+						m.bindMouseToTool(g.MouseButtonLeft, moveTool)
+					}),
+					g.Tooltip("move selection contents tool"),
 				),
 				g.Row(
 					g.ImageButton(icons.Textures[insertImage].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
@@ -231,16 +370,29 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 						m.bindMouseToTool(g.MouseButtonLeft, fillTool)
 					}),
 					g.Tooltip("fill tool"),
+					g.ImageButton(icons.Textures[scatterImage].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
+						m.bindMouseToTool(g.MouseButtonLeft, scatterTool)
+					}),
+					g.Tooltip("scatter tool"),
 				),
 				g.Row(
 					g.ImageButton(icons.Textures[pickImage].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
 						m.bindMouseToTool(g.MouseButtonLeft, pickTool)
 					}),
 					g.Tooltip("pick from map tool"),
+					g.ImageButton(icons.Textures[pathImage].Texture).Size(30, 30).FramePadding(0).OnClick(func() {
+						m.bindMouseToTool(g.MouseButtonLeft, pathTool)
+					}),
+					g.Tooltip("path test tool"),
 				),
 				g.Row(
 					g.Child().Size(150, g.Auto).Border(false).Layout(
 						g.Custom(func() {
+							m.layoutSymmetrySettings().Build()
+							m.layoutFloodSettings().Build()
+							if m.isToolBound(scatterTool) {
+								m.layoutScatterSettings().Build()
+							}
 							if !m.selectedCoords.Empty() {
 								// Draw our selection modification buttons
 								g.Column(
@@ -273,6 +425,23 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 				g.OpenPopup("Map Script")
 			} else if deleteMapPopup {
 				g.OpenPopup("Delete Map")
+			} else if restoreBackupPopup {
+				g.OpenPopup("Restore from Backup")
+			} else if tiledImportPopup {
+				g.OpenPopup("Import from Tiled")
+			} else if tiledExportPopup {
+				g.OpenPopup("Export to Tiled")
+			} else if generatePopup {
+				g.OpenPopup("Generate")
+			} else if terrainPopup {
+				g.OpenPopup("Terrain")
+			} else if mapOpPopup {
+				g.OpenPopup("Map Operation")
+			} else if selectByPopup {
+				g.OpenPopup("Select By")
+			} else if m.recovery.pending {
+				g.OpenPopup("Recover Unsaved Changes")
+				m.recovery.pending = false
 			}
 		}),
 		g.PopupModal("Save Map").Layout(
@@ -392,6 +561,7 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 					cm.SetDataName(m.newDataName)
 
 					cm.Set(clone)
+					m.selectArchetype()
 
 					m.newName, m.newDataName = "", ""
 				}),
@@ -416,6 +586,7 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 					clone.Script = m.scriptEditor.GetText()
 
 					cm.Set(clone)
+					m.selectArchetype()
 
 				}),
 				g.Button("Cancel").OnClick(func() {
@@ -426,6 +597,11 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 		g.PopupModal("Delete Map").Layout(
 			g.Label("Delete map?"),
 			g.Label("This cannot be recovered."),
+			g.Custom(func() {
+				if cm := m.CurrentMap(); cm != nil && cm.Unsaved() {
+					g.Label(fmt.Sprintf("%s has unsaved changes that will be discarded.", cm.DataName())).Build()
+				}
+			}),
 			g.Row(
 				g.Button("Delete").OnClick(func() {
 					m.deleteMap(m.currentMapIndex)
@@ -436,21 +612,23 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 				}),
 			),
 		),
+		m.layoutRestorePopup(),
+		m.layoutRecoveryPopup(),
+		m.layoutTiledImportPopup(),
+		m.layoutTiledExportPopup(),
+		m.layoutGeneratePopup(),
+		m.layoutTerrainPopup(),
+		m.layoutMapOperationPopup(),
+		m.layoutSelectByPopup(),
 		widgets.KeyBinds(widgets.KeyBindsFlagWindowFocused,
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(widgets.KeyShift, widgets.KeyControl), widgets.Keys(widgets.KeyZ), func() {
-				if cm := m.CurrentMap(); cm != nil {
-					cm.Redo()
-				}
+				m.redo()
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(widgets.KeyControl), widgets.Keys(widgets.KeyZ), func() {
-				if cm := m.CurrentMap(); cm != nil {
-					cm.Undo()
-				}
+				m.undo()
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(widgets.KeyControl), widgets.Keys(widgets.KeyY), func() {
-				if cm := m.CurrentMap(); cm != nil {
-					cm.Redo()
-				}
+				m.redo()
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(), widgets.Keys(widgets.KeyLeft), func() {
 				if m.focusedX > 0 {
@@ -490,17 +668,17 @@ func (m *Mapset) Draw() (title string, w *g.WindowWidget, layout g.Layout) {
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(), widgets.Keys(widgets.KeyA), func() {
 				if cm := m.CurrentMap(); cm != nil {
-					m.toolInsert(3, cm, m.focusedY, m.focusedX, m.focusedZ)
+					m.useTool(insertTool, Trigger, cm, m.focusedY, m.focusedX, m.focusedZ)
 				}
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(widgets.KeyControl), widgets.Keys(widgets.KeyF), func() {
 				if cm := m.CurrentMap(); cm != nil {
-					m.toolFill(3, cm, m.focusedY, m.focusedX, m.focusedZ)
+					m.useTool(fillTool, Trigger, cm, m.focusedY, m.focusedX, m.focusedZ)
 				}
 			}),
 			widgets.KeyBind(widgets.KeyBindFlagPressed, widgets.Keys(), widgets.Keys(widgets.KeyD), func() {
 				if cm := m.CurrentMap(); cm != nil {
-					m.toolErase(3, cm, m.focusedY, m.focusedX, m.focusedZ)
+					m.useTool(eraseTool, Trigger, cm, m.focusedY, m.focusedX, m.focusedZ)
 				}
 			}),
 		),
@@ -521,10 +699,18 @@ func (m *Mapset) layoutMapTabs() g.Layout {
 			if v.Unsaved() {
 				flags |= g.TabItemFlagsUnsavedDocument
 			}
+			if m.pendingTab == v {
+				flags |= g.TabItemFlagsSetSelected
+				m.pendingTab = nil
+			}
 			tab := g.TabItem(fmt.Sprintf("%s(%s)", v.DataName(), v.Get().Name)).Flags(flags).Layout(
 				g.Custom(func() {
 					if m.currentMapIndex != mapIndex {
 						m.currentMapIndex = mapIndex
+						if f := m.pendingFocus; f != nil {
+							m.focusedY, m.focusedX, m.focusedZ, m.focusedI = f[0], f[1], f[2], 0
+							m.pendingFocus = nil
+						}
 						m.ensure()
 						m.moveCursor(m.focusedY, m.focusedX, m.focusedZ, m.focusedI)
 					}
@@ -533,7 +719,7 @@ func (m *Mapset) layoutMapTabs() g.Layout {
 					defaultH := float32(math.Round(float64(availH - availH/4)))
 					g.SplitLayout(g.DirectionVertical, true, defaultH, g.Layout{
 						g.SplitLayout(g.DirectionHorizontal, true, defaultW,
-							m.layoutMapView(v),
+							m.layoutViewports(v),
 							g.Custom(func() {
 								_, h := g.GetAvailableRegion()
 								g.Child().Size(g.Auto, h-35).ID("archsView").Border(false).Layout(
@@ -546,8 +732,7 @@ func (m *Mapset) layoutMapTabs() g.Layout {
 												if m.focusedI+1 >= len(*t) {
 													// If the target is moving beyond the tile's count, move it up a y.
 													if err := m.move(cm, m.focusedY, m.focusedX, m.focusedZ, m.focusedI, m.focusedY+1, m.focusedX, m.focusedZ, 0); err == nil {
-														m.focusedY++
-														m.focusedI = 0
+														m.moveCursor(m.focusedY+1, m.focusedX, m.focusedZ, 0)
 													}
 												} else {
 													// Otherwise shift it within its tile.
@@ -565,10 +750,11 @@ func (m *Mapset) layoutMapTabs() g.Layout {
 												if m.focusedI == 0 {
 													// If the target is moving below 0, move it down a y.
 													if err := m.move(cm, m.focusedY, m.focusedX, m.focusedZ, m.focusedI, m.focusedY-1, m.focusedX, m.focusedZ, -1); err == nil {
-														m.focusedY--
-														if t := m.getTiles(cm.Get(), m.focusedY, m.focusedX, m.focusedZ); t != nil {
-															m.focusedI = len(*t) - 1
+														focusedI := m.focusedI
+														if t := m.getTiles(cm.Get(), m.focusedY-1, m.focusedX, m.focusedZ); t != nil {
+															focusedI = len(*t) - 1
 														}
+														m.moveCursor(m.focusedY-1, m.focusedX, m.focusedZ, focusedI)
 													}
 												} else {
 													// Otherwise shift it within its tile.
@@ -599,7 +785,39 @@ func (m *Mapset) layoutMapTabs() g.Layout {
 	return g.Layout{g.TabBar().Flags(g.TabBarFlagsFittingPolicyScroll | g.TabBarFlagsFittingPolicyResizeDown).TabItems(tabs...)}
 }
 
-func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
+func (m *Mapset) layoutViewports(v *data.UnReMap) g.Layout {
+	lineHeight := imgui.CalcTextSize("Toolbar", false, 0)
+
+	return g.Layout{
+		g.Custom(func() {
+			availW, availH := g.GetAvailableRegion()
+			var views g.Widget
+			switch len(m.viewports) {
+			case 2:
+				views = g.SplitLayout(g.DirectionHorizontal, true, availW/2, m.layoutMapView(0, v), m.layoutMapView(1, v))
+			case 3:
+				views = g.SplitLayout(g.DirectionVertical, true, (availH-lineHeight.Y*2)/2,
+					g.SplitLayout(g.DirectionHorizontal, true, availW/2, m.layoutMapView(0, v), m.layoutMapView(1, v)),
+					m.layoutMapView(2, v),
+				)
+			case 4:
+				views = g.SplitLayout(g.DirectionVertical, true, (availH-lineHeight.Y*2)/2,
+					g.SplitLayout(g.DirectionHorizontal, true, availW/2, m.layoutMapView(0, v), m.layoutMapView(1, v)),
+					g.SplitLayout(g.DirectionHorizontal, true, availW/2, m.layoutMapView(2, v), m.layoutMapView(3, v)),
+				)
+			default:
+				views = m.layoutMapView(0, v)
+			}
+			g.Child().Border(false).Flags(g.WindowFlagsNoScrollbar|imgui.WindowFlagsNoScrollWithMouse).Size(availW, availH-lineHeight.Y*2).Layout(
+				views,
+			).Build()
+		}),
+		m.layoutMapInfobar(v),
+	}
+}
+
+func (m *Mapset) layoutMapView(index int, v *data.UnReMap) g.Layout {
+	vp := m.viewports[index]
 	var availW, availH float32
 	childPos := image.Point{0, 0}
 	childFlags := g.WindowFlagsHorizontalScrollbar | imgui.WindowFlagsNoMove | imgui.WindowFlagsNoNav
@@ -607,39 +825,41 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 		childFlags |= imgui.WindowFlagsNoScrollWithMouse
 	}
 	hovered := false
-	lineHeight := imgui.CalcTextSize("Toolbar", false, 0)
 	var canvasWidth, canvasHeight float32
 
 	return g.Layout{
 		g.Custom(func() {
 			availW, availH = g.GetAvailableRegion()
-			g.Child().Border(false).Flags(childFlags).Size(availW, availH-lineHeight.Y*2).Layout(
+			g.Child().Border(len(m.viewports) > 1 && index == m.activeViewportIndex).Flags(childFlags).Size(availW, availH).Layout(
 
 				g.Custom(func() {
 					childPos = g.GetCursorScreenPos()
-					canvasWidth, canvasHeight = m.getMapSize(v)
+					scrollX, scrollY := imgui.ScrollX(), imgui.ScrollY()
+					vp.viewRect = image.Rect(int(scrollX), int(scrollY), int(scrollX+availW), int(scrollY+availH))
+					canvasWidth, canvasHeight = m.getMapSize(vp, v)
 					g.Child().Border(false).Flags(g.WindowFlagsNoMouseInputs|g.WindowFlagsNoMove).Size(canvasWidth, canvasHeight).Layout(
 						g.Custom(func() {
-							m.drawMap(v)
+							m.drawMap(vp, v)
 						}),
 					).Build()
 				}),
 				g.Custom(func() {
 					if g.IsItemHovered() {
 						hovered = true
+						m.activeViewportIndex = index
 						mousePos := g.GetMousePos()
 						mousePos.X -= childPos.X
 						mousePos.Y -= childPos.Y
 
-						p, err := m.getMapPointFromMouse(mousePos)
+						py, px, pz, err := m.getMapPointFromMouse(vp, mousePos)
 						if err != nil {
 							//log.Errorln(err)
 							return
 						}
 
-						m.hoveredY = m.focusedY
-						m.hoveredX = p.X
-						m.hoveredZ = p.Y
+						m.hoveredY = py
+						m.hoveredX = px
+						m.hoveredZ = pz
 
 						var state ButtonState
 						// RMB
@@ -649,13 +869,13 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 								m.mouseHeld[g.MouseButtonRight] = true
 								state = 1
 							}
-							err := m.handleMouseTool(g.MouseButtonRight, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonRight, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
 						} else if g.IsMouseReleased(g.MouseButtonRight) {
 							state = 0
-							err := m.handleMouseTool(g.MouseButtonRight, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonRight, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
@@ -669,13 +889,13 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 								m.mouseHeld[g.MouseButtonMiddle] = true
 								state = 1
 							}
-							err := m.handleMouseTool(g.MouseButtonMiddle, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonMiddle, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
 						} else if g.IsMouseReleased(g.MouseButtonMiddle) {
 							state = 0
-							err := m.handleMouseTool(g.MouseButtonMiddle, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonMiddle, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
@@ -688,13 +908,13 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 								m.mouseHeld[g.MouseButtonLeft] = true
 								state = 1
 							}
-							err := m.handleMouseTool(g.MouseButtonLeft, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonLeft, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
 						} else if g.IsMouseReleased(g.MouseButtonLeft) {
 							state = 0
-							err := m.handleMouseTool(g.MouseButtonLeft, state, m.focusedY, p.X, p.Y)
+							err := m.handleMouseTool(g.MouseButtonLeft, state, py, px, pz)
 							if err != nil {
 								log.Errorln(err)
 							}
@@ -744,11 +964,11 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 							widgets.KeyBind(widgets.KeyBindFlagDown, widgets.Keys(), widgets.Keys(widgets.KeyControl), func() {
 								mouseWheelDelta, _ := g.Context.IO().GetMouseWheelDelta(), g.Context.IO().GetMouseWheelHDelta()
 								if mouseWheelDelta != 0 {
-									m.zoom += int32(mouseWheelDelta)
-									if m.zoom < 1 {
-										m.zoom = 1
-									} else if m.zoom > 8 {
-										m.zoom = 8
+									vp.zoom += int32(mouseWheelDelta)
+									if vp.zoom < 1 {
+										vp.zoom = 1
+									} else if vp.zoom > 8 {
+										vp.zoom = 8
 									}
 								}
 							}),
@@ -757,7 +977,6 @@ func (m *Mapset) layoutMapView(v *data.UnReMap) g.Layout {
 				}),
 			).Build()
 		}),
-		m.layoutMapInfobar(v),
 	}
 }
 
@@ -841,6 +1060,28 @@ func (m *Mapset) layoutMapInfobar(v *data.UnReMap) g.Layout {
 				g.Dummy(float32(dm.AnimationsConfig.TileWidth), float32(dm.AnimationsConfig.TileHeight))
 			}),
 			g.Label(hoveredArchName),
+			g.Custom(func() {
+				if m.activeViewport().showLighting {
+					g.SameLine()
+					level := m.getLightMap(v).Get(m.hoveredY, m.hoveredX, m.hoveredZ)
+					if level <= pitchBlackLevel {
+						g.Label("light: pitch-black").Build()
+					} else {
+						g.Label(fmt.Sprintf("light: %d%%", int(level*100))).Build()
+					}
+				}
+			}),
+			g.Custom(func() {
+				if m.isToolBound(pathTool) {
+					g.SameLine()
+					route, err := m.getPathRoute(v)
+					if err != nil {
+						g.Label(fmt.Sprintf("path: %s", err)).Build()
+					} else {
+						g.Label(fmt.Sprintf("path: %d steps", len(route)-1)).Build()
+					}
+				}
+			}),
 		),
 	}
 }
@@ -931,12 +1172,22 @@ func (m *Mapset) layoutArchsList(v *data.UnReMap) g.Layout {
 func (m *Mapset) scrollFocus(v *data.UnReMap) {
 	mouseWheelDelta, _ := g.Context.IO().GetMouseWheelDelta(), g.Context.IO().GetMouseWheelHDelta()
 	if mouseWheelDelta != 0 {
-		y := m.focusedY + int(mouseWheelDelta)
-		if y < 0 {
-			y = 0
-		} else if y >= v.Get().Height {
-			y = v.Get().Height - 1
+		clamp := func(i, max int) int {
+			if i < 0 {
+				return 0
+			} else if i >= max {
+				return max - 1
+			}
+			return i
+		}
+		// Cross-sections scroll through the axis they slice.
+		switch m.activeViewport().viewMode {
+		case viewCrossXY:
+			m.moveCursor(m.focusedY, m.focusedX, clamp(m.focusedZ+int(mouseWheelDelta), v.Get().Depth), m.focusedI)
+		case viewCrossZY:
+			m.moveCursor(m.focusedY, clamp(m.focusedX+int(mouseWheelDelta), v.Get().Width), m.focusedZ, m.focusedI)
+		default:
+			m.moveCursor(clamp(m.focusedY+int(mouseWheelDelta), v.Get().Height), m.focusedX, m.focusedZ, m.focusedI)
 		}
-		m.moveCursor(y, m.focusedX, m.focusedZ, m.focusedI)
 	}
 }
@@ -0,0 +1,188 @@
+package mapview
+
+import (
+	"errors"
+	"image"
+
+	sdata "github.com/chimera-rpg/go-server/data"
+)
+
+// View modes for the map canvas.
+const (
+	viewOblique = iota // Oblique top-down projection built from the YStep.
+	viewCrossXY        // X/Y cross-section at the focused Z.
+	viewCrossZY        // Z/Y cross-section at the focused X.
+	viewTopDown        // Strict top-down of the viewport's focused Y.
+)
+
+var viewModeNames = []string{"Oblique", "X/Y Cross-section", "Z/Y Cross-section", "Top-down"}
+
+const maxViewports = 4
+
+// viewport is a single view onto the current map. Each viewport has its own zoom, focused Y, onionskinning, and view mode, while the cursor and selection are shared by the Mapset.
+type viewport struct {
+	zoom                                       int32
+	focusedY                                   int
+	viewMode                                   int
+	showGrid                                   bool
+	showYGrids                                 bool
+	showLighting                               bool
+	showWalkability                            bool
+	onionskinY, onionskinX, onionskinZ         bool
+	onionSkinGtIntensity, onionSkinLtIntensity int32
+	viewRect                                   image.Rectangle // Visible region of the map canvas, relative to the canvas origin.
+	drawCache                                  mapDrawCache
+}
+
+func newViewport() *viewport {
+	return &viewport{
+		zoom:                 3.0,
+		showGrid:             false,
+		showYGrids:           false,
+		onionskinY:           true,
+		onionskinX:           false,
+		onionskinZ:           false,
+		onionSkinGtIntensity: 240,
+		onionSkinLtIntensity: 10,
+	}
+}
+
+// clone returns a copy of the viewport's settings without its cached state.
+func (vp *viewport) clone() *viewport {
+	n := *vp
+	n.viewRect = image.Rectangle{}
+	n.drawCache = mapDrawCache{}
+	return &n
+}
+
+// setViewMode changes the view mode of the viewport.
+func (vp *viewport) setViewMode(mode int) {
+	vp.viewMode = mode
+	vp.drawCache.Invalidate()
+}
+
+// activeViewport returns the viewport that was last interacted with.
+func (m *Mapset) activeViewport() *viewport {
+	if m.activeViewportIndex < 0 || m.activeViewportIndex >= len(m.viewports) {
+		m.activeViewportIndex = 0
+	}
+	return m.viewports[m.activeViewportIndex]
+}
+
+// setViewportCount adds or removes viewports so that there are count viewports.
+func (m *Mapset) setViewportCount(count int) {
+	if count < 1 {
+		count = 1
+	} else if count > maxViewports {
+		count = maxViewports
+	}
+	for len(m.viewports) < count {
+		m.viewports = append(m.viewports, m.activeViewport().clone())
+	}
+	m.viewports = m.viewports[:count]
+	if m.activeViewportIndex >= count {
+		m.activeViewportIndex = count - 1
+	}
+}
+
+// viewSlice returns the coordinate along the axis that the viewport's view mode slices through.
+func (m *Mapset) viewSlice(vp *viewport) int {
+	switch vp.viewMode {
+	case viewCrossXY:
+		return m.focusedZ
+	case viewCrossZY:
+		return m.focusedX
+	case viewTopDown:
+		return vp.focusedY
+	}
+	return 0
+}
+
+// isTileInView returns whether the given tile is shown by the viewport's view mode.
+func (m *Mapset) isTileInView(vp *viewport, y, x, z int) bool {
+	switch vp.viewMode {
+	case viewCrossXY:
+		return z == m.focusedZ
+	case viewCrossZY:
+		return x == m.focusedX
+	case viewTopDown:
+		return y == vp.focusedY
+	}
+	return true
+}
+
+// getViewSize returns the unscaled canvas size of the map for the viewport's view mode.
+func (m *Mapset) getViewSize(vp *viewport, sm *sdata.Map) (int, int) {
+	dm := m.context.DataManager()
+	tWidth := int(dm.AnimationsConfig.TileWidth)
+	tHeight := int(dm.AnimationsConfig.TileHeight)
+	yStep := dm.AnimationsConfig.YStep
+	padding := 4
+
+	switch vp.viewMode {
+	case viewCrossXY:
+		return sm.Width*tWidth + padding*2, sm.Height*tHeight + padding*2
+	case viewCrossZY:
+		return sm.Depth*tWidth + padding*2, sm.Height*tHeight + padding*2
+	case viewTopDown:
+		return sm.Width*tWidth + padding*2, sm.Depth*tHeight + padding*2
+	}
+	return sm.Width*tWidth + (sm.Height * int(yStep.X)) + padding*2, sm.Depth*tHeight + (sm.Height * int(-yStep.Y)) + padding*2
+}
+
+// projectTile returns the unscaled canvas position of the given tile's origin for the viewport's view mode.
+func (m *Mapset) projectTile(vp *viewport, sm *sdata.Map, y, x, z int) (int, int) {
+	dm := m.context.DataManager()
+	tWidth := int(dm.AnimationsConfig.TileWidth)
+	tHeight := int(dm.AnimationsConfig.TileHeight)
+	yStep := dm.AnimationsConfig.YStep
+	padding := 4
+
+	switch vp.viewMode {
+	case viewCrossXY:
+		return x*tWidth + padding, (sm.Height-1-y)*tHeight + padding
+	case viewCrossZY:
+		return z*tWidth + padding, (sm.Height-1-y)*tHeight + padding
+	case viewTopDown:
+		return x*tWidth + padding, z*tHeight + padding
+	}
+	startX := padding
+	startY := padding + (sm.Height * int(-yStep.Y))
+	xOffset := y * int(yStep.X)
+	yOffset := y * int(-yStep.Y)
+	return x*tWidth + xOffset + startX, z*tHeight - yOffset + startY
+}
+
+// getTileFromView returns the tile coordinates at the given unscaled canvas position for the viewport's view mode.
+func (m *Mapset) getTileFromView(vp *viewport, sm *sdata.Map, hitX, hitY int) (y, x, z int, err error) {
+	dm := m.context.DataManager()
+	tWidth := int(dm.AnimationsConfig.TileWidth)
+	tHeight := int(dm.AnimationsConfig.TileHeight)
+	yStep := dm.AnimationsConfig.YStep
+	padding := 4
+
+	y, x, z = vp.focusedY, m.focusedX, m.focusedZ
+	if vp.viewMode != viewOblique && (hitX < padding || hitY < padding) {
+		return y, x, z, errors.New("Point OOB")
+	}
+	switch vp.viewMode {
+	case viewCrossXY:
+		x = (hitX - padding) / tWidth
+		y = sm.Height - 1 - (hitY-padding)/tHeight
+	case viewCrossZY:
+		z = (hitX - padding) / tWidth
+		y = sm.Height - 1 - (hitY-padding)/tHeight
+	case viewTopDown:
+		x = (hitX - padding) / tWidth
+		z = (hitY - padding) / tHeight
+	default:
+		xOffset := vp.focusedY*int(-yStep.X) + padding
+		yOffset := vp.focusedY*int(yStep.Y) + padding + (sm.Height * int(-yStep.Y))
+		x = (hitX+xOffset)/tWidth - 1
+		z = (hitY - yOffset) / tHeight
+	}
+	if y < 0 || y >= sm.Height || x < 0 || x >= sm.Width || z < 0 || z >= sm.Depth {
+		err = errors.New("Point OOB")
+	}
+	return
+}
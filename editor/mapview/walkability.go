@@ -0,0 +1,349 @@
+package mapview
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	cdata "github.com/chimera-rpg/go-common/data"
+	"github.com/chimera-rpg/go-editor/data"
+)
+
+// Walkability classes of a tile.
+const (
+	tileEmpty     = iota // Open space with nothing to stand on.
+	tileBlocking         // Occupied by blocking matter or too small for the mover.
+	tileWalkable         // Free space with ground to stand on.
+	tileClimbable        // Walkable, and can be reached by climbing up from a lower neighboring tile.
+)
+
+// walkMap holds the walkability of every tile in a map for a given revision and mover size. A mover standing at a tile fills the space from that tile through its height, width, and depth.
+type walkMap struct {
+	v                    *data.UnReMap
+	revision             int
+	height, width, depth int
+	mapWidth, mapDepth   int
+	valid                bool
+	solid                [][][]bool // [y][x][z] tiles occupied by blocking matter.
+	classes              [][][]int  // [y][x][z] walkability classes.
+}
+
+// Invalidate forces the walk map to be recomputed.
+func (w *walkMap) Invalidate() {
+	w.valid = false
+	w.solid = nil
+	w.classes = nil
+}
+
+// Get returns the walkability class at the given coordinates.
+func (w *walkMap) Get(y, x, z int) int {
+	if y < 0 || y >= len(w.classes) || x < 0 || x >= len(w.classes[y]) || z < 0 || z >= len(w.classes[y][x]) {
+		return tileEmpty
+	}
+	return w.classes[y][x][z]
+}
+
+// Standable returns whether a mover can stand at the given coordinates.
+func (w *walkMap) Standable(y, x, z int) bool {
+	c := w.Get(y, x, z)
+	return c == tileWalkable || c == tileClimbable
+}
+
+// isSolid returns whether the given coordinates are occupied by blocking matter. Coordinates outside of the map are open.
+func (w *walkMap) isSolid(y, x, z int) bool {
+	if y < 0 || y >= len(w.solid) || x < 0 || x >= len(w.solid[y]) || z < 0 || z >= len(w.solid[y][x]) {
+		return false
+	}
+	return w.solid[y][x][z]
+}
+
+// footprintClear returns whether the mover's footprint at the given level lies within the map and is free of blocking matter.
+func (w *walkMap) footprintClear(y, x, z int) bool {
+	for ax := x; ax < x+w.width; ax++ {
+		for az := z; az < z+w.depth; az++ {
+			if ax >= w.mapWidth || az >= w.mapDepth || w.isSolid(y, ax, az) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getWalkMap returns the walk map for the given map, recomputing it if the map or mover size has changed.
+func (m *Mapset) getWalkMap(v *data.UnReMap) *walkMap {
+	height, width, depth := int(m.moverHeight), int(m.moverWidth), int(m.moverDepth)
+	if height < 1 {
+		height = 1
+	}
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	if m.walk.valid && m.walk.v == v && m.walk.revision == v.Revision() && m.walk.height == height && m.walk.width == width && m.walk.depth == depth {
+		return &m.walk
+	}
+	dm := m.context.DataManager()
+	sm := v.Get()
+
+	solid := make([][][]bool, sm.Height)
+	floor := make([][][]bool, sm.Height)
+	classes := make([][][]int, sm.Height)
+	for y := 0; y < sm.Height; y++ {
+		solid[y] = make([][]bool, sm.Width)
+		floor[y] = make([][]bool, sm.Width)
+		classes[y] = make([][]int, sm.Width)
+		for x := 0; x < sm.Width; x++ {
+			solid[y][x] = make([]bool, sm.Depth)
+			floor[y][x] = make([]bool, sm.Depth)
+			classes[y][x] = make([]int, sm.Depth)
+		}
+	}
+
+	// Mark the tiles covered by blocking archetypes, extending through their dimensions, and the tiles with floors.
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				for t := range sm.Tiles[y][x][z] {
+					arch := &sm.Tiles[y][x][z][t]
+					atype := dm.GetArchType(arch, 0)
+					_, blocking := dm.GetArchMatter(arch)
+					if atype == cdata.ArchetypeBlock || blocking != 0 {
+						h, w, d := dm.GetArchDimensions(arch)
+						if h == 0 {
+							h = 1
+						}
+						if w == 0 {
+							w = 1
+						}
+						if d == 0 {
+							d = 1
+						}
+						for ay := y; ay < y+int(h); ay++ {
+							for ax := x; ax < x+int(w); ax++ {
+								for az := z; az < z+int(d); az++ {
+									if ay < sm.Height && ax < sm.Width && az < sm.Depth {
+										solid[ay][ax][az] = true
+									}
+								}
+							}
+						}
+					} else if atype == cdata.ArchetypeTile {
+						floor[y][x][z] = true
+					}
+				}
+			}
+		}
+	}
+
+	w := walkMap{
+		v:        v,
+		revision: v.Revision(),
+		height:   height,
+		width:    width,
+		depth:    depth,
+		mapWidth: sm.Width,
+		mapDepth: sm.Depth,
+		valid:    true,
+		solid:    solid,
+		classes:  classes,
+	}
+
+	// Classify each tile by whether a mover of the given size could stand in it, with ground under some part of its footprint.
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				if solid[y][x][z] {
+					classes[y][x][z] = tileBlocking
+					continue
+				}
+				grounded := false
+				for ax := x; ax < x+width && ax < sm.Width && !grounded; ax++ {
+					for az := z; az < z+depth && az < sm.Depth; az++ {
+						if floor[y][ax][az] || w.isSolid(y-1, ax, az) {
+							grounded = true
+							break
+						}
+					}
+				}
+				if !grounded {
+					continue
+				}
+				fits := true
+				for h := 0; h < height; h++ {
+					if !w.footprintClear(y+h, x, z) {
+						fits = false
+						break
+					}
+				}
+				if !fits {
+					classes[y][x][z] = tileBlocking
+					continue
+				}
+				classes[y][x][z] = tileWalkable
+			}
+		}
+	}
+	// Mark walkable tiles that a mover can climb up to from a neighboring tile one level lower, using the same headroom rule as findPath.
+	for y := 1; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			for z := 0; z < sm.Depth; z++ {
+				if classes[y][x][z] != tileWalkable {
+					continue
+				}
+				for _, n := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+					if w.Standable(y-1, x+n[0], z+n[1]) && w.footprintClear(y-1+height, x+n[0], z+n[1]) {
+						classes[y][x][z] = tileClimbable
+						break
+					}
+				}
+			}
+		}
+	}
+
+	m.walk = w
+	return &m.walk
+}
+
+// pathNode is an entry in the path search's open set.
+type pathNode struct {
+	coord    [3]int
+	cost     int
+	estimate int
+}
+
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].cost+q[i].estimate < q[j].cost+q[j].estimate }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// findPath runs an A* search across the walk map from start to goal. Movers step to adjacent tiles, climbing up or dropping down at most one level per step. If no route exists, the returned error describes why.
+func (w *walkMap) findPath(start, goal [3]int) ([][3]int, error) {
+	describe := func(name string, c [3]int) error {
+		switch w.Get(c[0], c[1], c[2]) {
+		case tileBlocking:
+			return fmt.Errorf("%s tile %dx%dx%d is blocked", name, c[1], c[2], c[0])
+		case tileEmpty:
+			return fmt.Errorf("%s tile %dx%dx%d has no ground", name, c[1], c[2], c[0])
+		}
+		return nil
+	}
+	if err := describe("start", start); err != nil {
+		return nil, err
+	}
+	if err := describe("goal", goal); err != nil {
+		return nil, err
+	}
+
+	abs := func(i int) int {
+		if i < 0 {
+			return -i
+		}
+		return i
+	}
+	estimate := func(c [3]int) int {
+		return abs(goal[1]-c[1]) + abs(goal[2]-c[2])
+	}
+
+	costs := map[[3]int]int{start: 0}
+	from := make(map[[3]int][3]int)
+	open := &pathQueue{{coord: start, estimate: estimate(start)}}
+	for open.Len() > 0 {
+		n := heap.Pop(open).(pathNode)
+		if n.coord == goal {
+			route := [][3]int{goal}
+			for c := goal; c != start; {
+				c = from[c]
+				route = append([][3]int{c}, route...)
+			}
+			return route, nil
+		}
+		if n.cost > costs[n.coord] {
+			continue
+		}
+		y, x, z := n.coord[0], n.coord[1], n.coord[2]
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			for _, dy := range []int{0, 1, -1} {
+				next := [3]int{y + dy, x + d[0], z + d[1]}
+				if !w.Standable(next[0], next[1], next[2]) {
+					continue
+				}
+				cost := n.cost + 1
+				if dy > 0 {
+					// Climbing needs headroom above the mover.
+					if !w.footprintClear(y+w.height, x, z) {
+						continue
+					}
+					cost++
+				} else if dy < 0 && !w.footprintClear(y+w.height-1, next[1], next[2]) {
+					// Dropping needs the way over the edge to be open for the mover's full height.
+					continue
+				}
+				if c, ok := costs[next]; ok && c <= cost {
+					continue
+				}
+				costs[next] = cost
+				from[next] = n.coord
+				heap.Push(open, pathNode{coord: next, cost: cost, estimate: estimate(next)})
+			}
+		}
+	}
+	return nil, fmt.Errorf("goal is unreachable from start (%d tiles explored)", len(costs))
+}
+
+// pathTest holds the state of the path-test tool.
+type pathTest struct {
+	v                 *data.UnReMap
+	revision          int
+	height            int
+	width, depth      int
+	start, goal       [3]int
+	hasStart, hasGoal bool
+	searched          bool
+	route             [][3]int
+	err               error
+}
+
+// setPathPoint sets the start of a new path test, or its goal if a start is already pending.
+func (m *Mapset) setPathPoint(v *data.UnReMap, y, x, z int) {
+	if m.path.v != v || !m.path.hasStart || m.path.hasGoal {
+		m.path = pathTest{
+			v:        v,
+			start:    [3]int{y, x, z},
+			hasStart: true,
+		}
+		return
+	}
+	m.path.goal = [3]int{y, x, z}
+	m.path.hasGoal = true
+	m.path.searched = false
+}
+
+// getPathRoute returns the route for the current path test, searching again if the map or mover size has changed.
+func (m *Mapset) getPathRoute(v *data.UnReMap) ([][3]int, error) {
+	if m.path.v != v || !m.path.hasStart {
+		return nil, errors.New("no start picked")
+	}
+	if !m.path.hasGoal {
+		return nil, errors.New("no goal picked")
+	}
+	walk := m.getWalkMap(v)
+	if !m.path.searched || m.path.revision != walk.revision || m.path.height != walk.height || m.path.width != walk.width || m.path.depth != walk.depth {
+		m.path.route, m.path.err = walk.findPath(m.path.start, m.path.goal)
+		m.path.revision = walk.revision
+		m.path.height = walk.height
+		m.path.width = walk.width
+		m.path.depth = walk.depth
+		m.path.searched = true
+	}
+	return m.path.route, m.path.err
+}
@@ -56,6 +56,20 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	// Run any command given on the command line.
+	var setupEditor func(e *editor.Editor)
+	if len(os.Args) > 1 {
+		c, ok := commands[os.Args[1]]
+		if !ok {
+			usage()
+			os.Exit(2)
+		}
+		var status int
+		if status, setupEditor = c.run(&dataManager, os.Args[2:]); setupEditor == nil {
+			os.Exit(status)
+		}
+	}
+
 	// Setup our UI
 	/*if err = uiInstance.Setup(&dataManager); err != nil {
 		ui.ShowError("%s", err)
@@ -82,11 +96,17 @@ func main() {
 		return
 	}
 	defer editorInstance.Destroy()
+	if setupEditor != nil {
+		setupEditor(&editorInstance)
+	}
 
 	// Add cleanup handling on kill.
 	sigChan := make(chan os.Signal, 2)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
+		// The first signal autosaves and asks about unsaved changes, a second one exits immediately.
+		<-sigChan
+		editorInstance.RequestExit()
 		<-sigChan
 		if err := dataManager.EditorConfig.Save(); err != nil {
 			log.Errorln(err)
@@ -103,4 +123,6 @@ func main() {
 	}
 
 	log.Print("Sayonara!")
+	// Closing the window fails the same as quitting while merge conflicts are unresolved.
+	os.Exit(editorInstance.ExitCode())
 }
@@ -31,10 +31,12 @@ type StringPair struct {
 	initialInt    int32
 	previousInt   int32
 	reset         bool
+	mixed         bool // The edited archetypes have differing values.
 }
 
 type ArchEditorWidget struct {
 	arch               *sdata.Archetype
+	instances          []*sdata.Archetype // Every archetype being edited if more than one, starting with arch.
 	descEditor         imgui.TextEditor
 	context            Context
 	pairs              map[string]*StringPair
@@ -68,6 +70,7 @@ func (a *ArchEditorWidget) ResetCallbacks() {
 	a.requestUndo = nil
 	a.requestSave = nil
 	a.arch = nil
+	a.instances = nil
 }
 
 func (a *ArchEditorWidget) SetPreChangeCallback(f func() bool) {
@@ -90,15 +93,39 @@ func (a *ArchEditorWidget) SetRedoCallback(f func() bool) {
 }
 
 func (a *ArchEditorWidget) SetArchetype(arch *sdata.Archetype) {
-	if arch == a.arch {
+	if arch == a.arch && a.instances == nil {
 		return
 	}
 	a.arch = arch
+	a.instances = nil
 
 	//
 	a.Refresh()
 }
 
+// SetArchetypes edits all of the given archetypes at once. Fields that differ between them are shown as mixed, and applied changes are written to every one of them.
+func (a *ArchEditorWidget) SetArchetypes(archs []*sdata.Archetype) {
+	if len(archs) <= 1 {
+		var arch *sdata.Archetype
+		if len(archs) == 1 {
+			arch = archs[0]
+		}
+		a.SetArchetype(arch)
+		return
+	}
+	a.arch = archs[0]
+	a.instances = archs
+	a.Refresh()
+}
+
+// targets returns the archetypes that changes are written to.
+func (a *ArchEditorWidget) targets() []*sdata.Archetype {
+	if a.instances != nil {
+		return a.instances
+	}
+	return []*sdata.Archetype{a.arch}
+}
+
 func (a *ArchEditorWidget) Refresh() {
 	if a.arch == nil {
 		return
@@ -112,10 +139,26 @@ func (a *ArchEditorWidget) Refresh() {
 	a.pairs["SoundIndex"] = a.getStringPair("SoundIndex")
 }
 
+// getStringPair returns the field's values for the edited archetypes, marking them as mixed if they differ.
 func (a *ArchEditorWidget) getStringPair(field string) *StringPair {
+	s := a.getArchStringPair(a.arch, field)
+	for _, arch := range a.targets()[1:] {
+		o := a.getArchStringPair(arch, field)
+		if o.pendingStr != s.pendingStr || o.pendingInt != s.pendingInt || o.pendingFloat != s.pendingFloat {
+			s.mixed = true
+			s.pendingStr, s.initialStr = "", ""
+			s.pendingInt, s.initialInt = 0, 0
+			s.pendingFloat, s.initialFloat = 0, 0
+			break
+		}
+	}
+	return s
+}
+
+func (a *ArchEditorWidget) getArchStringPair(arch *sdata.Archetype, field string) *StringPair {
 	dm := a.context.DataManager()
 	s := StringPair{}
-	v1 := dm.GetArchAncestryField(a.arch, field)
+	v1 := dm.GetArchAncestryField(arch, field)
 	if v1.IsValid() {
 		s.previous = v1
 		if s.previous.Kind() == reflect.Ptr && !s.previous.IsNil() {
@@ -134,7 +177,7 @@ func (a *ArchEditorWidget) getStringPair(field string) *StringPair {
 			}
 		}
 	}
-	v2 := dm.GetArchField(a.arch, field)
+	v2 := dm.GetArchField(arch, field)
 	if v2.IsValid() {
 		s.pending = v2
 		s.initial = v2
@@ -165,37 +208,13 @@ func (a *ArchEditorWidget) getStringPair(field string) *StringPair {
 
 func (a *ArchEditorWidget) checkStringPair(field string, s *StringPair) {
 	if s.reset {
-		a.context.DataManager().ClearArchField(a.arch, field)
+		for _, arch := range a.targets() {
+			a.context.DataManager().ClearArchField(arch, field)
+		}
 		s.reset = false
 	} else if s.initialStr != s.pendingStr || s.initialInt != s.pendingInt || s.initialFloat != s.pendingFloat {
-		if s.pending.Kind() == reflect.Ptr {
-			if s.pending.Type().Elem().Kind() == reflect.String {
-				if err := a.context.DataManager().SetArchField(a.arch, field, s.pendingStr); err != nil {
-					log.Println(err)
-				}
-			}
-		} else {
-			if s.pending.Kind() == reflect.String {
-				if err := a.context.DataManager().SetArchField(a.arch, field, s.pendingStr); err != nil {
-					log.Println(err)
-				}
-			} else if s.pending.Kind() == reflect.Float32 {
-				if err := a.context.DataManager().SetArchField(a.arch, field, s.pendingFloat); err != nil {
-					log.Println(err)
-				}
-			} else if s.pending.Kind() == reflect.Uint {
-				if err := a.context.DataManager().SetArchField(a.arch, field, uint32(s.pendingInt)); err != nil {
-					log.Println(err)
-				}
-			} else if s.pending.Kind() == reflect.Int {
-				if err := a.context.DataManager().SetArchField(a.arch, field, int32(s.pendingInt)); err != nil {
-					log.Println(err)
-				}
-			} else if s.pending.Kind() == reflect.Int8 {
-				if err := a.context.DataManager().SetArchField(a.arch, field, int8(s.pendingInt)); err != nil {
-					log.Println(err)
-				}
-			}
+		for _, arch := range a.targets() {
+			a.setStringPair(arch, field, s)
 		}
 		s.initial = s.pending
 		s.initialStr = s.pendingStr
@@ -204,6 +223,39 @@ func (a *ArchEditorWidget) checkStringPair(field string, s *StringPair) {
 	}
 }
 
+// setStringPair writes the pending value of the field to the archetype.
+func (a *ArchEditorWidget) setStringPair(arch *sdata.Archetype, field string, s *StringPair) {
+	if s.pending.Kind() == reflect.Ptr {
+		if s.pending.Type().Elem().Kind() == reflect.String {
+			if err := a.context.DataManager().SetArchField(arch, field, s.pendingStr); err != nil {
+				log.Println(err)
+			}
+		}
+	} else {
+		if s.pending.Kind() == reflect.String {
+			if err := a.context.DataManager().SetArchField(arch, field, s.pendingStr); err != nil {
+				log.Println(err)
+			}
+		} else if s.pending.Kind() == reflect.Float32 {
+			if err := a.context.DataManager().SetArchField(arch, field, s.pendingFloat); err != nil {
+				log.Println(err)
+			}
+		} else if s.pending.Kind() == reflect.Uint {
+			if err := a.context.DataManager().SetArchField(arch, field, uint32(s.pendingInt)); err != nil {
+				log.Println(err)
+			}
+		} else if s.pending.Kind() == reflect.Int {
+			if err := a.context.DataManager().SetArchField(arch, field, int32(s.pendingInt)); err != nil {
+				log.Println(err)
+			}
+		} else if s.pending.Kind() == reflect.Int8 {
+			if err := a.context.DataManager().SetArchField(arch, field, int8(s.pendingInt)); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
 func (a *ArchEditorWidget) Layout() (l g.Layout) {
 	l = g.Layout{
 		g.MenuBar().Layout(),
@@ -225,6 +277,11 @@ func (a *ArchEditorWidget) StringLayout(title, field, tooltip string) g.Layout {
 	if !isLocal || target.reset {
 		resetButton = g.Dummy(0, 0)
 	}
+	var mixedLabel g.Widget = g.Dummy(0, 0)
+	if target.mixed && target.initialStr == target.pendingStr && target.initialInt == target.pendingInt && target.initialFloat == target.pendingFloat {
+		mixedLabel = g.Label("(mixed)")
+		tooltip += " The edited archetypes have different values, entering one sets it for all of them."
+	}
 
 	var inputField g.Widget
 	if field == "Description" {
@@ -261,6 +318,7 @@ func (a *ArchEditorWidget) StringLayout(title, field, tooltip string) g.Layout {
 		g.Row(
 			inputField,
 			g.Tooltip(tooltip),
+			mixedLabel,
 			resetButton,
 		),
 		g.Custom(func() {
@@ -284,6 +342,8 @@ func (a *ArchEditorWidget) ArchetypeLayout() (l g.Layout) {
 	var label *g.LabelWidget
 	if len(missing) > 0 {
 		label = g.Label(fmt.Sprintf("Missing: %v", missing))
+	} else if a.instances != nil {
+		label = g.Label(fmt.Sprintf("Editing %d instances", len(a.instances)))
 	} else {
 		label = g.Label("")
 	}